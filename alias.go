@@ -0,0 +1,49 @@
+package goconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxAliasDepth is the number of aliases ResolveAlias expands in a chain
+// before it fails with ErrAliasDepth.
+const MaxAliasDepth = 10
+
+// ResolveAlias returns the expansion of the git alias name, as set by
+// alias.<name>. If the expansion starts with '!', it is a shell command:
+// ResolveAlias returns it without the '!' and shell set. Otherwise, if the
+// first word of the expansion is itself an alias, that word is expanded in
+// turn, like git does, up to MaxAliasDepth aliases. An alias that refers
+// back to itself returns ErrAliasCycle, a name that is not an alias
+// ErrKeyNotFound.
+func ResolveAlias(cfg map[string]string, name string) (expansion string, shell bool, err error) {
+	expansion, err = lookup(cfg, "alias."+name)
+	if err != nil {
+		return "", false, err
+	}
+	seen := []string{strings.ToLower(name)}
+	for {
+		if strings.HasPrefix(expansion, "!") {
+			return expansion[1:], true, nil
+		}
+		expansion = strings.TrimLeft(expansion, " \t")
+		first, rest := expansion, ""
+		if i := strings.IndexAny(expansion, " \t"); i >= 0 {
+			first, rest = expansion[:i], expansion[i:]
+		}
+		next, ok := cfg[normalizeKey("alias."+first)]
+		if first == "" || !ok {
+			return expansion, false, nil
+		}
+		for _, alias := range seen {
+			if alias == strings.ToLower(first) {
+				return "", false, fmt.Errorf("%w: %s", ErrAliasCycle, strings.Join(append(seen, first), " -> "))
+			}
+		}
+		if len(seen) == MaxAliasDepth {
+			return "", false, fmt.Errorf("%w: %s", ErrAliasDepth, name)
+		}
+		seen = append(seen, strings.ToLower(first))
+		expansion = next + rest
+	}
+}
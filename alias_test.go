@@ -0,0 +1,67 @@
+package goconfig
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveAlias(t *testing.T) {
+	config, _, err := ParseString(`[alias]
+	co = checkout
+	st = status -sb
+	lg = log --graph --oneline
+	last = lg -1 HEAD
+	l = last --stat
+	up = !git fetch && git rebase
+	sync = up --autostash
+	empty =`)
+	assert.Equal(t, nil, err)
+
+	for name, expected := range map[string]struct {
+		expansion string
+		shell     bool
+	}{
+		"co":    {expansion: "checkout"},
+		"CO":    {expansion: "checkout"},
+		"st":    {expansion: "status -sb"},
+		"last":  {expansion: "log --graph --oneline -1 HEAD"},
+		"l":     {expansion: "log --graph --oneline -1 HEAD --stat"},
+		"up":    {expansion: "git fetch && git rebase", shell: true},
+		"sync":  {expansion: "git fetch && git rebase --autostash", shell: true},
+		"empty": {},
+	} {
+		expansion, shell, err := ResolveAlias(config, name)
+		assert.Equal(t, nil, err, name)
+		assert.Equal(t, expected.expansion, expansion, name)
+		assert.Equal(t, expected.shell, shell, name)
+	}
+
+	_, _, err = ResolveAlias(config, "missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestResolveAliasCycle(t *testing.T) {
+	config := map[string]string{
+		"alias.a":    "b --x",
+		"alias.b":    "c",
+		"alias.c":    "a",
+		"alias.self": "self",
+	}
+	_, _, err := ResolveAlias(config, "a")
+	assert.ErrorIs(t, err, ErrAliasCycle)
+	assert.Equal(t, "alias cycle: a -> b -> c -> a", err.Error())
+	_, _, err = ResolveAlias(config, "self")
+	assert.ErrorIs(t, err, ErrAliasCycle)
+
+	config = map[string]string{}
+	for i := 0; i <= MaxAliasDepth; i++ {
+		config[fmt.Sprintf("alias.a%d", i)] = fmt.Sprintf("a%d", i+1)
+	}
+	_, _, err = ResolveAlias(config, "a0")
+	assert.ErrorIs(t, err, ErrAliasDepth)
+	expansion, _, err := ResolveAlias(config, "a2")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, fmt.Sprintf("a%d", MaxAliasDepth+1), expansion)
+}
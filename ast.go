@@ -0,0 +1,256 @@
+package goconfig
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Node is one piece of a configuration file as returned by ParseNodes.
+// Raw returns the exact text of the node, so that joining the Raw text of
+// all nodes reproduces the input byte for byte.
+type Node interface {
+	Raw() string
+}
+
+// SectionNode is a section header, together with the rest of its line
+// (e.g. a trailing comment).
+type SectionNode struct {
+	Section    string
+	Subsection string
+	Text       string
+}
+
+// KeyNode is a variable definition, from the indentation before its name
+// to the end of its value, including an inline comment, continuation
+// lines and the line ending. Section and Subsection are those of the
+// header above it; Key is lowercase as in Parse.
+type KeyNode struct {
+	Section    string
+	Subsection string
+	Key        string
+	Value      string
+	Text       string
+}
+
+// CommentNode is a line holding nothing but a comment.
+type CommentNode struct {
+	Text string
+}
+
+// BlankNode is an empty or whitespace-only line.
+type BlankNode struct {
+	Text string
+}
+
+// Raw returns the text of the header.
+func (n *SectionNode) Raw() string { return n.Text }
+
+// Raw returns the text of the definition.
+func (n *KeyNode) Raw() string { return n.Text }
+
+// Raw returns the text of the line.
+func (n *CommentNode) Raw() string { return n.Text }
+
+// Raw returns the text of the line.
+func (n *BlankNode) Raw() string { return n.Text }
+
+// ParseNodes parses the given bytes like Parse, but returns the file as an
+// ordered list of nodes that keeps comments, blank lines and the original
+// spelling of every line. Use MarshalNodes to write it back and Edit to
+// change a value.
+func ParseNodes(b []byte, opts ...Option) ([]Node, error) {
+	cf, err := newBytesParser(b, opts)
+	if err != nil {
+		return nil, err
+	}
+	nb := &nodeBuilder{src: b}
+	var section, subsection string
+	cf.onSection = func(name string, start int64) {
+		section, subsection = splitSection(name)
+		text := nb.take(int(start), int(cf.offset))
+		nb.nodes = append(nb.nodes, &SectionNode{Section: section, Subsection: subsection, Text: text})
+	}
+	_, err = cf.run(func(name, key, value string) error {
+		text := nb.take(int(cf.keyOffset), int(cf.offset))
+		nb.nodes = append(nb.nodes, &KeyNode{
+			Section:    section,
+			Subsection: subsection,
+			Key:        key,
+			Value:      value,
+			Text:       text,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rest := nb.gap(len(b)); rest != "" {
+		nb.line(rest)
+	}
+	return nb.nodes, nil
+}
+
+// MarshalNodes joins the text of nodes. For nodes returned by ParseNodes
+// this is the original input.
+func MarshalNodes(nodes []Node) []byte {
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		buf.WriteString(n.Raw())
+	}
+	return buf.Bytes()
+}
+
+// Edit sets key to value in nodes, like `git config key value`, and
+// returns the updated list. Only one line changes: if key is defined, its
+// last definition is rewritten, keeping the indentation and the spelling
+// of the name but dropping an inline comment; otherwise a new line is
+// inserted at the end of the last block of the section, or a new section
+// is appended. The key is validated as in Set. Like append, Edit may
+// modify the underlying array of nodes.
+func Edit(nodes []Node, key, value string) ([]Node, error) {
+	section, subsection, name, err := splitFlatKey(normalizeKey(key))
+	if err != nil {
+		return nodes, err
+	}
+	last := -1
+	for i := len(nodes) - 1; i >= 0; i-- {
+		switch n := nodes[i].(type) {
+		case *SectionNode:
+			if last < 0 && n.Section == section && n.Subsection == subsection {
+				last = i
+			}
+		case *KeyNode:
+			if n.Section != section || n.Subsection != subsection {
+				continue
+			}
+			if n.Key == name {
+				nodes[i] = &KeyNode{
+					Section:    section,
+					Subsection: subsection,
+					Key:        name,
+					Value:      value,
+					Text:       editLine(n.Text, value),
+				}
+				return nodes, nil
+			}
+			if last < 0 {
+				last = i
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if last < 0 {
+		last = len(nodes) - 1
+		if last >= 0 && !endsLine(nodes[last].Raw()) {
+			buf.WriteByte('\n')
+		}
+		writeHeader(&buf, section, subsection, subsection != "")
+		nodes = append(nodes, &SectionNode{Section: section, Subsection: subsection, Text: buf.String()})
+		buf.Reset()
+		last = len(nodes) - 1
+	} else if !endsLine(nodes[last].Raw()) {
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\t')
+	buf.WriteString(name)
+	buf.WriteString(" = ")
+	writeValue(&buf, value)
+	buf.WriteByte('\n')
+	node := &KeyNode{Section: section, Subsection: subsection, Key: name, Value: value, Text: buf.String()}
+	nodes = append(nodes, nil)
+	copy(nodes[last+2:], nodes[last+1:])
+	nodes[last+1] = node
+	return nodes, nil
+}
+
+// editLine replaces the value in the text of a KeyNode.
+func editLine(text, value string) string {
+	head := strings.TrimRight(text, "\r\n")
+	eol := text[len(head):]
+	if eol == "" {
+		eol = "\n"
+	}
+	if i := strings.IndexByte(head, '='); i >= 0 {
+		rest := head[i+1:]
+		head = head[:i+1] + rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))]
+	} else {
+		head = strings.TrimRight(head, " \t") + " = "
+	}
+	var buf bytes.Buffer
+	buf.WriteString(head)
+	writeValue(&buf, value)
+	buf.WriteString(eol)
+	return buf.String()
+}
+
+// nodeBuilder splits the input into nodes. The parser reports where
+// headers and keys are; the text in between is made of comments and
+// whitespace.
+type nodeBuilder struct {
+	src   []byte
+	nodes []Node
+	// pos is the end of the text covered by nodes.
+	pos int
+}
+
+// take returns the text of the node at src[start:end], preceded by the
+// indentation before it, and turns the lines in between into nodes.
+func (nb *nodeBuilder) take(start, end int) string {
+	text := nb.gap(start) + string(nb.src[start:end])
+	nb.pos = end
+	return text
+}
+
+// gap turns the lines of src[pos:end] into nodes and returns the text
+// after the last line ending. The rest of a header line is added to the
+// header.
+func (nb *nodeBuilder) gap(end int) string {
+	text := string(nb.src[nb.pos:end])
+	nb.pos = end
+	if len(nb.nodes) > 0 {
+		if h, ok := nb.nodes[len(nb.nodes)-1].(*SectionNode); ok && !endsLine(h.Text) {
+			i := lineEnd(text)
+			if i < 0 {
+				i = len(text)
+			}
+			h.Text += text[:i]
+			text = text[i:]
+		}
+	}
+	for {
+		i := lineEnd(text)
+		if i < 0 {
+			return text
+		}
+		nb.line(text[:i])
+		text = text[i:]
+	}
+}
+
+// lineEnd returns the length of the first line of text including its line
+// ending, which is "\n", "\r\n" or a lone '\r' as in Parse, or -1 if text
+// has no line ending.
+func lineEnd(text string) int {
+	i := strings.IndexAny(text, "\r\n")
+	if i < 0 {
+		return -1
+	}
+	if text[i] == '\r' && i+1 < len(text) && text[i+1] == '\n' {
+		return i + 2
+	}
+	return i + 1
+}
+
+// endsLine reports whether text ends with a line ending.
+func endsLine(text string) bool {
+	return strings.HasSuffix(text, "\n") || strings.HasSuffix(text, "\r")
+}
+
+func (nb *nodeBuilder) line(text string) {
+	if strings.TrimSpace(text) == "" {
+		nb.nodes = append(nb.nodes, &BlankNode{Text: text})
+	} else {
+		nb.nodes = append(nb.nodes, &CommentNode{Text: text})
+	}
+}
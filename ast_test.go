@@ -0,0 +1,139 @@
+package goconfig
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const commentedConfig = "\xef\xbb\xbf# global settings\r\n" +
+	"[user]   ; me\r\n" +
+	"\tname = Danyel  # inline\r\n" +
+	"\r\n" +
+	"\temail=cydrop@gmail.com\r\n" +
+	"[remote \"origin\"] url = a \\\n" +
+	"  b\n" +
+	"  ; trailing\n" +
+	"    \n" +
+	"[core]\n" +
+	"\tbare"
+
+func TestParseNodes(t *testing.T) {
+	nodes, err := ParseNodes([]byte(commentedConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []Node{
+		&CommentNode{Text: "\xef\xbb\xbf# global settings\r\n"},
+		&SectionNode{Section: "user", Text: "[user]   ; me\r\n"},
+		&KeyNode{Section: "user", Key: "name", Value: "Danyel", Text: "\tname = Danyel  # inline\r\n"},
+		&BlankNode{Text: "\r\n"},
+		&KeyNode{Section: "user", Key: "email", Value: "cydrop@gmail.com", Text: "\temail=cydrop@gmail.com\r\n"},
+		&SectionNode{Section: "remote", Subsection: "origin", Text: "[remote \"origin\"] "},
+		&KeyNode{Section: "remote", Subsection: "origin", Key: "url", Value: "a   b", Text: "url = a \\\n  b\n"},
+		&CommentNode{Text: "  ; trailing\n"},
+		&BlankNode{Text: "    \n"},
+		&SectionNode{Section: "core", Text: "[core]\n"},
+		&KeyNode{Section: "core", Key: "bare", Text: "\tbare"},
+	}, nodes)
+	assert.Equal(t, commentedConfig, string(MarshalNodes(nodes)))
+
+	_, err = ParseNodes([]byte("[user]\n\tna@me = x\n"))
+	assert.ErrorIs(t, err, ErrInvalidKeyChar)
+}
+
+// TestParseNodesCR checks that a lone '\r' ends lines as in Parse.
+func TestParseNodesCR(t *testing.T) {
+	input := "# top comment\r[user] ; me\r# about name\r\tname = x\r\r# about core\r[core]\r\tbare\r"
+	nodes, err := ParseNodes([]byte(input))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []Node{
+		&CommentNode{Text: "# top comment\r"},
+		&SectionNode{Section: "user", Text: "[user] ; me\r"},
+		&CommentNode{Text: "# about name\r"},
+		&KeyNode{Section: "user", Key: "name", Value: "x", Text: "\tname = x\r"},
+		&BlankNode{Text: "\r"},
+		&CommentNode{Text: "# about core\r"},
+		&SectionNode{Section: "core", Text: "[core]\r"},
+		&KeyNode{Section: "core", Key: "bare", Text: "\tbare\r"},
+	}, nodes)
+	assert.Equal(t, input, string(MarshalNodes(nodes)))
+
+	nodes, err = Edit(nodes, "core.editor", "vi")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, input+"\teditor = vi\n", string(MarshalNodes(nodes)))
+}
+
+func TestParseNodesRoundTrip(t *testing.T) {
+	for _, filename := range []string{"configs/danyel.gitconfig"} {
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("Reading file %v failed", filename)
+		}
+		nodes, err := ParseNodes(b)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, string(b), string(MarshalNodes(nodes)))
+	}
+	for _, input := range []string{"", "\n", "# only\n", "[a]", "[a]\n\tb = c\n\n; end"} {
+		nodes, err := ParseNodes([]byte(input))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, input, string(MarshalNodes(nodes)), input)
+	}
+}
+
+func TestEdit(t *testing.T) {
+	nodes, err := ParseNodes([]byte(commentedConfig))
+	assert.Equal(t, nil, err)
+
+	nodes, err = Edit(nodes, "User.Name", "Someone Else")
+	assert.Equal(t, nil, err)
+	nodes, err = Edit(nodes, "user.email", " padded ")
+	assert.Equal(t, nil, err)
+	nodes, err = Edit(nodes, "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*")
+	assert.Equal(t, nil, err)
+	nodes, err = Edit(nodes, "core.bare", "false")
+	assert.Equal(t, nil, err)
+	nodes, err = Edit(nodes, "core.editor", "vim")
+	assert.Equal(t, nil, err)
+	nodes, err = Edit(nodes, "http.https://example.com.sslVerify", "false")
+	assert.Equal(t, nil, err)
+
+	expected := "\xef\xbb\xbf# global settings\r\n" +
+		"[user]   ; me\r\n" +
+		"\tname = Someone Else\r\n" +
+		"\r\n" +
+		"\temail=\" padded \"\r\n" +
+		"[remote \"origin\"] url = a \\\n" +
+		"  b\n" +
+		"\tfetch = +refs/heads/*:refs/remotes/origin/*\n" +
+		"  ; trailing\n" +
+		"    \n" +
+		"[core]\n" +
+		"\tbare = false\n" +
+		"\teditor = vim\n" +
+		"[http \"https://example.com\"]\n" +
+		"\tsslverify = false\n"
+	assert.Equal(t, expected, string(MarshalNodes(nodes)))
+
+	config, _, err := Parse(MarshalNodes(nodes))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Someone Else", config["user.name"])
+	assert.Equal(t, " padded ", config["user.email"])
+	assert.Equal(t, "a   b", config["remote.origin.url"])
+
+	_, err = Edit(nodes, "nodot", "x")
+	assert.ErrorIs(t, err, ErrInvalidKeyChar)
+
+	nodes, err = Edit(nil, "user.name", "x")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "[user]\n\tname = x\n", string(MarshalNodes(nodes)))
+}
+
+func TestEditLastDefinition(t *testing.T) {
+	nodes, err := ParseNodes([]byte("[a]\n\tk = 1\n[b]\n\tk = 2\n[a]\n\tk = 3 ; last\n\tother = x\n"))
+	assert.Equal(t, nil, err)
+	nodes, err = Edit(nodes, "a.k", "4")
+	assert.Equal(t, nil, err)
+	nodes, err = Edit(nodes, "a.new", "5")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "[a]\n\tk = 1\n[b]\n\tk = 2\n[a]\n\tk = 4\n\tother = x\n\tnew = 5\n", string(MarshalNodes(nodes)))
+}
@@ -0,0 +1,40 @@
+package goconfig
+
+import "strings"
+
+// BranchConfig is the upstream of a branch as set by git branch
+// --set-upstream-to or git push -u.
+type BranchConfig struct {
+	// Remote is branch.<name>.remote, the remote to fetch from, or "." for
+	// a local upstream.
+	Remote string
+	// Merge is branch.<name>.merge, the upstream branch on that remote,
+	// usually a full ref such as "refs/heads/main".
+	Merge string
+}
+
+// Branches returns the branches that set branch.<name>.remote or
+// branch.<name>.merge in cfg, by name. Names are the subsections as
+// written, so they keep their slashes, dots and case, as in
+// branch.feature/x.remote for the branch "feature/x". Other variables of
+// the section, such as description, are ignored.
+func Branches(cfg map[string]string) map[string]BranchConfig {
+	branches := map[string]BranchConfig{}
+	for key, value := range cfg {
+		section, name, variable := SplitKey(key)
+		if strings.ToLower(section) != "branch" || name == "" {
+			continue
+		}
+		b := branches[name]
+		switch strings.ToLower(variable) {
+		case "remote":
+			b.Remote = value
+		case "merge":
+			b.Merge = value
+		default:
+			continue
+		}
+		branches[name] = b
+	}
+	return branches
+}
@@ -0,0 +1,41 @@
+package goconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBranches(t *testing.T) {
+	config, _, err := Parse([]byte(`[branch "main"]
+	remote = origin
+	merge = refs/heads/main
+[branch "feature/x"]
+	remote = upstream
+	merge = refs/heads/feature/x
+[branch "release.1.0"]
+	merge = refs/heads/release.1.0
+	remote = .
+[branch "Topic"]
+	description = not tracking
+[branch "only-remote"]
+	remote = origin
+[branch]
+	autoSetupMerge = always
+[remote "origin"]
+	url = https://example.com/repo.git
+`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]BranchConfig{
+		"main":        {Remote: "origin", Merge: "refs/heads/main"},
+		"feature/x":   {Remote: "upstream", Merge: "refs/heads/feature/x"},
+		"release.1.0": {Remote: ".", Merge: "refs/heads/release.1.0"},
+		"only-remote": {Remote: "origin"},
+	}, Branches(config))
+	assert.Equal(t, "upstream", config["branch.feature/x.remote"])
+
+	assert.Equal(t, map[string]BranchConfig{}, Branches(nil))
+	assert.Equal(t, map[string]BranchConfig{
+		"Dev": {Merge: "refs/heads/dev"},
+	}, Branches(map[string]string{"branch.Dev.Merge": "refs/heads/dev"}))
+}
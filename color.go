@@ -0,0 +1,209 @@
+package goconfig
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var colorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// colorAttrs maps attribute names to their SGR code and the code that
+// turns them off again.
+var colorAttrs = map[string][2]int{
+	"bold":      {1, 22},
+	"dim":       {2, 22},
+	"italic":    {3, 23},
+	"ul":        {4, 24},
+	"underline": {4, 24},
+	"blink":     {5, 25},
+	"reverse":   {7, 27},
+	"strike":    {9, 29},
+}
+
+// GetColor returns the value of key, or defaultColor if key is not set,
+// converted to an ANSI escape sequence like git config --get-color does.
+// The key is normalized as in Get.
+//
+// The value is a space separated list of words. The first color is the
+// foreground, the second the background. Colors are the names black, red,
+// green, yellow, blue, magenta, cyan and white (optionally prefixed by
+// "bright"), normal (no change), default, a number between -1 and 255 or an
+// RGB value #rrggbb. Attributes are bold, dim, italic, ul/underline, blink,
+// reverse and strike, each of which may be prefixed by "no" or "no-" to turn
+// it off, and reset. An empty value yields the empty string.
+func GetColor(cfg map[string]string, key, defaultColor string) (string, error) {
+	value, ok := find(cfg, key, nil)
+	if !ok {
+		value = defaultColor
+	}
+	color, err := parseColor(value)
+	if err != nil {
+		return "", fmt.Errorf("%w for %s: %v", ErrInvalidColor, key, err)
+	}
+	return color, nil
+}
+
+// ColorMode is the value of color.ui and the other settings that switch
+// colored output on or off, as returned by GetColorMode.
+type ColorMode int
+
+const (
+	// ColorNever turns colors off.
+	ColorNever ColorMode = iota
+	// ColorAlways turns colors on, even if the output is not a terminal.
+	ColorAlways
+	// ColorAuto leaves the decision to the caller, which uses colors only
+	// if the output is a terminal.
+	ColorAuto
+)
+
+func (m ColorMode) String() string {
+	switch m {
+	case ColorNever:
+		return "never"
+	case ColorAlways:
+		return "always"
+	case ColorAuto:
+		return "auto"
+	}
+	return "ColorMode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// GetColorMode returns the value of key as a color mode, like git reads
+// color.ui or color.diff: never, always and auto are the modes of the same
+// name, and any other boolean as understood by GetBool is ColorAuto if it
+// is true and ColorNever if it is false. Case is ignored. Other values
+// return an ErrInvalidColorMode error.
+func GetColorMode(cfg map[string]string, key string) (ColorMode, error) {
+	value, err := lookup(cfg, key)
+	if err != nil {
+		return ColorNever, err
+	}
+	switch strings.ToLower(value) {
+	case "never":
+		return ColorNever, nil
+	case "always":
+		return ColorAlways, nil
+	case "auto":
+		return ColorAuto, nil
+	}
+	b, ok := parseBool(value)
+	if !ok {
+		return ColorNever, fmt.Errorf("%w for %s: %q", ErrInvalidColorMode, key, value)
+	}
+	if b {
+		return ColorAuto, nil
+	}
+	return ColorNever, nil
+}
+
+func parseColor(value string) (string, error) {
+	var reset bool
+	var attrs []int
+	var colors []string
+	for _, word := range strings.Fields(strings.ToLower(value)) {
+		if word == "reset" {
+			reset = true
+			continue
+		}
+		if code, ok := parseColorAttr(word); ok {
+			attrs = append(attrs, code)
+			continue
+		}
+		if len(colors) == 2 {
+			return "", fmt.Errorf("%q", word)
+		}
+		color, ok := parseColorWord(word, len(colors) == 1)
+		if !ok {
+			return "", fmt.Errorf("%q", word)
+		}
+		colors = append(colors, color)
+	}
+	sort.Ints(attrs)
+
+	var codes []string
+	for i, code := range attrs {
+		if i == 0 || code != attrs[i-1] {
+			codes = append(codes, strconv.Itoa(code))
+		}
+	}
+	for _, color := range colors {
+		if color != "" {
+			codes = append(codes, color)
+		}
+	}
+	if !reset && len(codes) == 0 {
+		return "", nil
+	}
+	if reset && len(codes) > 0 {
+		return "\033[;" + strings.Join(codes, ";") + "m", nil
+	}
+	return "\033[" + strings.Join(codes, ";") + "m", nil
+}
+
+func parseColorAttr(word string) (int, bool) {
+	if attr, ok := colorAttrs[word]; ok {
+		return attr[0], true
+	}
+	if strings.HasPrefix(word, "no") {
+		if attr, ok := colorAttrs[strings.TrimPrefix(word[2:], "-")]; ok {
+			return attr[1], true
+		}
+	}
+	return 0, false
+}
+
+// parseColorWord returns the SGR parameters for a single color, or the
+// empty string for normal.
+func parseColorWord(word string, background bool) (string, bool) {
+	base, escape := 30, "38"
+	if background {
+		base, escape = 40, "48"
+	}
+	switch word {
+	case "normal":
+		return "", true
+	case "default":
+		return strconv.Itoa(base + 9), true
+	}
+	for i, name := range colorNames {
+		switch word {
+		case name:
+			return strconv.Itoa(base + i), true
+		case "bright" + name:
+			return strconv.Itoa(base + 60 + i), true
+		}
+	}
+	if strings.HasPrefix(word, "#") {
+		return parseRGB(word[1:], escape)
+	}
+	n, err := strconv.Atoi(word)
+	switch {
+	case err != nil || n < -1 || n > 255:
+		return "", false
+	case n == -1:
+		return "", true
+	case n < 8:
+		return strconv.Itoa(base + n), true
+	case n < 16:
+		return strconv.Itoa(base + 60 + n - 8), true
+	}
+	return escape + ";5;" + strconv.Itoa(n), true
+}
+
+func parseRGB(hex, escape string) (string, bool) {
+	if len(hex) != 6 {
+		return "", false
+	}
+	rgb := make([]string, 3)
+	for i := range rgb {
+		n, err := strconv.ParseUint(hex[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return "", false
+		}
+		rgb[i] = strconv.FormatUint(n, 10)
+	}
+	return escape + ";2;" + strings.Join(rgb, ";"), true
+}
@@ -0,0 +1,86 @@
+package goconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetColor(t *testing.T) {
+	tests := map[string]string{
+		"":                      "",
+		"normal":                "",
+		"red":                   "\033[31m",
+		"bold green":            "\033[1;32m",
+		"Green Bold":            "\033[1;32m",
+		"brightblue":            "\033[94m",
+		"red blue":              "\033[31;44m",
+		"normal blue":           "\033[44m",
+		"default":               "\033[39m",
+		"7":                     "\033[37m",
+		"9":                     "\033[91m",
+		"208":                   "\033[38;5;208m",
+		"-1 208":                "\033[48;5;208m",
+		"#ff0000":               "\033[38;2;255;0;0m",
+		"white #00ff7f":         "\033[37;48;2;0;255;127m",
+		"reverse ul bold dim":   "\033[1;2;4;7m",
+		"nobold no-ul":          "\033[22;24m",
+		"reset":                 "\033[m",
+		"reset yellow":          "\033[;33m",
+		"italic strike magenta": "\033[3;9;35m",
+	}
+	for value, expected := range tests {
+		color, err := GetColor(map[string]string{"color.diff.new": value}, "color.diff.new", "")
+		assert.Equal(t, nil, err, value)
+		assert.Equal(t, expected, color, value)
+	}
+
+	color, err := GetColor(map[string]string{"color.diff.meta": "bold"}, "Color.diff.Meta", "red")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "\033[1m", color)
+	color, err = GetColor(map[string]string{"color.diff.meta": "bold"}, "color.DIFF.meta", "red")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "\033[31m", color)
+}
+
+func TestGetColorDefault(t *testing.T) {
+	color, err := GetColor(map[string]string{}, "color.diff.old", "red bold")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "\033[1;31m", color)
+}
+
+func TestGetColorInvalid(t *testing.T) {
+	for _, value := range []string{"purple", "red green blue", "256", "#ff00", "#gg0000", "bright"} {
+		_, err := GetColor(map[string]string{"color.ui": value}, "color.ui", "")
+		assert.ErrorIs(t, err, ErrInvalidColor, value)
+	}
+	_, err := GetColor(map[string]string{"color.ui": "bold purple"}, "color.ui", "")
+	assert.Contains(t, err.Error(), `"purple"`)
+}
+
+func TestGetColorMode(t *testing.T) {
+	tests := map[string]ColorMode{
+		"never":  ColorNever,
+		"Always": ColorAlways,
+		"auto":   ColorAuto,
+		"true":   ColorAuto,
+		"on":     ColorAuto,
+		"":       ColorAuto,
+		"false":  ColorNever,
+		"0":      ColorNever,
+	}
+	for value, expected := range tests {
+		mode, err := GetColorMode(map[string]string{"color.ui": value}, "Color.UI")
+		assert.Equal(t, nil, err, value)
+		assert.Equal(t, expected, mode, value)
+	}
+
+	_, err := GetColorMode(map[string]string{"color.ui": "sometimes"}, "color.ui")
+	assert.ErrorIs(t, err, ErrInvalidColorMode)
+	assert.EqualError(t, err, `invalid color mode for color.ui: "sometimes"`)
+	_, err = GetColorMode(map[string]string{}, "color.ui")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	assert.Equal(t, "auto", ColorAuto.String())
+	assert.Equal(t, "ColorMode(7)", ColorMode(7).String())
+}
@@ -0,0 +1,161 @@
+package goconfig
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// CredentialHelpers returns the credential helpers git would run for url,
+// in order: the value of credential.helper followed by the values of
+// credential.<pattern>.helper for every pattern that matches url, from the
+// least to the most specific. An empty value clears the helpers before it,
+// as in git, so a more specific section can turn off general helpers.
+//
+// A pattern matches like in git's urlmatch: its scheme and host must be
+// those of url, where a '*' in the host matches one label ("*.example.com"
+// matches "git.example.com" but not "example.com"); its port, user name
+// and path must match if it has them, where the path matches url's path
+// and everything below it. A pattern without scheme, like "example.com",
+// matches any scheme. Patterns are more specific if their path is longer,
+// then if they name a user, then if their host has no wildcard.
+//
+// Since a map does not keep the file order that git uses, patterns of the
+// same specificity are ordered by name. A flat map also holds only the
+// last helper of each section; use CredentialHelpersMulti with the result
+// of ParseMulti to get all of them.
+func CredentialHelpers(cfg map[string]string, url string) []string {
+	return CredentialHelpersMulti(toMulti(cfg), url)
+}
+
+// CredentialHelpersMulti is like CredentialHelpers for a configuration as
+// returned by ParseMulti: every value of a helper key is a helper, in the
+// order of the file, and an empty value clears the helpers before it.
+func CredentialHelpersMulti(cfg map[string][]string, url string) []string {
+	target, ok := parseCredentialURL(url)
+	if !ok {
+		return nil
+	}
+	var matches []credentialMatch
+	for key, values := range cfg {
+		if !strings.HasPrefix(key, "credential.") {
+			continue
+		}
+		section, subsection, name := SplitKey(key)
+		if section != "credential" || name != "helper" {
+			continue
+		}
+		if key == "credential.helper" {
+			matches = append(matches, credentialMatch{values: values, score: -1})
+			continue
+		}
+		pattern, ok := parseCredentialURL(subsection)
+		if !ok || !pattern.matches(target) {
+			continue
+		}
+		matches = append(matches, credentialMatch{
+			pattern: subsection,
+			values:  values,
+			score:   pattern.specificity(),
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].pattern < matches[j].pattern
+	})
+	var helpers []string
+	for _, m := range matches {
+		for _, value := range m.values {
+			if value == "" {
+				helpers = nil
+				continue
+			}
+			helpers = append(helpers, value)
+		}
+	}
+	return helpers
+}
+
+type credentialMatch struct {
+	pattern string
+	values  []string
+	score   int
+}
+
+// credentialURL is a URL normalized for matching.
+type credentialURL struct {
+	scheme string
+	user   string
+	host   string
+	port   string
+	path   string
+}
+
+var defaultPorts = map[string]string{"http": "80", "https": "443", "ssh": "22", "git": "9418"}
+
+// parseCredentialURL parses a URL or a partial URL without scheme.
+func parseCredentialURL(raw string) (credentialURL, bool) {
+	partial := !strings.Contains(raw, "://")
+	if partial {
+		raw = "partial://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return credentialURL{}, false
+	}
+	c := credentialURL{
+		scheme: strings.ToLower(u.Scheme),
+		host:   strings.ToLower(u.Hostname()),
+		port:   u.Port(),
+		path:   strings.Trim(u.Path, "/"),
+	}
+	if partial {
+		c.scheme = ""
+	}
+	if u.User != nil {
+		c.user = u.User.Username()
+	}
+	if c.port == defaultPorts[c.scheme] {
+		c.port = ""
+	}
+	return c, true
+}
+
+// matches reports whether the pattern p matches the URL u.
+func (p credentialURL) matches(u credentialURL) bool {
+	switch {
+	case p.scheme != "" && p.scheme != u.scheme:
+		return false
+	case p.user != "" && p.user != u.user:
+		return false
+	case p.port != u.port && (p.scheme != "" || p.port != ""):
+		return false
+	case p.path != "" && p.path != u.path && !strings.HasPrefix(u.path, p.path+"/"):
+		return false
+	}
+	labels, want := strings.Split(p.host, "."), strings.Split(u.host, ".")
+	if len(labels) != len(want) {
+		return false
+	}
+	for i, label := range labels {
+		if label != "*" && label != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// specificity orders matching patterns: a longer path is more specific,
+// then a user name, then a host without wildcards.
+func (p credentialURL) specificity() int {
+	score := len(p.path) * 4
+	if p.user != "" {
+		score += 2
+	}
+	if !strings.Contains(p.host, "*") {
+		score++
+	}
+	return score
+}
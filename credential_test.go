@@ -0,0 +1,77 @@
+package goconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialHelpers(t *testing.T) {
+	config, _, err := Parse([]byte(`[credential]
+	helper = cache
+[credential "https://github.com"]
+	helper = store
+[credential "https://github.com/corp/repo.git"]
+	helper = corp-repo
+[credential "https://GitHub.com/corp"]
+	helper = corp
+[credential "https://alice@github.com"]
+	helper = alice
+[credential "https://*.example.com"]
+	helper = wildcard
+[credential "https://git.example.com:8443"]
+	helper = port
+[credential "example.org"]
+	helper = partial
+[credential "https://private.example.net"]
+	helper =
+[credential "https://private.example.net/team"]
+	helper = team
+[credential "http://github.com"]
+	helper = insecure`))
+	assert.Equal(t, nil, err)
+
+	tests := map[string][]string{
+		"https://github.com/other/repo.git":        {"cache", "store"},
+		"https://github.com/corp/repo.git":         {"cache", "store", "corp", "corp-repo"},
+		"https://github.com/corp/repo.git/":        {"cache", "store", "corp", "corp-repo"},
+		"https://github.com/corporate/x.git":       {"cache", "store"},
+		"https://alice@github.com/corp/x.git":      {"cache", "store", "alice", "corp"},
+		"https://github.com:443/x":                 {"cache", "store"},
+		"https://git.example.com/x":                {"cache", "wildcard"},
+		"https://git.example.com:8443/x":           {"cache", "port"},
+		"https://example.com/x":                    {"cache"},
+		"https://a.b.example.com/x":                {"cache"},
+		"ssh://example.org/x":                      {"cache", "partial"},
+		"https://example.org/x":                    {"cache", "partial"},
+		"https://private.example.net/x":            nil,
+		"https://private.example.net/team/app.git": {"team"},
+		"http://github.com/x":                      {"cache", "insecure"},
+	}
+	for url, expected := range tests {
+		assert.Equal(t, expected, CredentialHelpers(config, url), url)
+	}
+
+	assert.Nil(t, CredentialHelpers(config, "not a url"))
+	assert.Nil(t, CredentialHelpers(map[string]string{}, "https://github.com"))
+}
+
+func TestCredentialHelpersMulti(t *testing.T) {
+	input := `[credential]
+	helper = cache
+	helper = store
+[credential "https://github.com"]
+	helper =
+	helper = manager
+	helper = gh`
+	config, _, err := ParseMulti([]byte(input))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"cache", "store"}, CredentialHelpersMulti(config, "https://example.com/x"))
+	assert.Equal(t, []string{"manager", "gh"}, CredentialHelpersMulti(config, "https://github.com/x"))
+
+	// The flat map keeps only the last helper of a section.
+	flat, _, err := ParseString(input)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"store"}, CredentialHelpers(flat, "https://example.com/x"))
+	assert.Nil(t, CredentialHelpersMulti(config, "not a url"))
+}
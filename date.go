@@ -0,0 +1,98 @@
+package goconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Never is returned by GetExpiry for the values never and false. It is the
+// zero time, so callers can test for it with IsZero.
+var Never = time.Time{}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon Jan 2 15:04:05 2006 -0700",
+}
+
+var dateUnits = map[string]func(t time.Time, n int) time.Time{
+	"second": func(t time.Time, n int) time.Time { return t.Add(-time.Duration(n) * time.Second) },
+	"minute": func(t time.Time, n int) time.Time { return t.Add(-time.Duration(n) * time.Minute) },
+	"hour":   func(t time.Time, n int) time.Time { return t.Add(-time.Duration(n) * time.Hour) },
+	"day":    func(t time.Time, n int) time.Time { return t.AddDate(0, 0, -n) },
+	"week":   func(t time.Time, n int) time.Time { return t.AddDate(0, 0, -7*n) },
+	"month":  func(t time.Time, n int) time.Time { return t.AddDate(0, -n, 0) },
+	"year":   func(t time.Time, n int) time.Time { return t.AddDate(-n, 0, 0) },
+}
+
+// GetExpiry returns the value of key as an expiry date, as used by
+// gc.reflogExpire and similar keys. never and false return Never, now and
+// all return the current time. Relative dates such as "90.days.ago",
+// "2.weeks" or "1 year 6 months ago" count back from the current time, with
+// or without the trailing ago. Absolute dates are accepted in ISO 8601
+// (2006-01-02, 2006-01-02 15:04:05, RFC 3339) and RFC 2822 form.
+func GetExpiry(cfg map[string]string, key string) (time.Time, error) {
+	return Getter{}.GetExpiry(cfg, key)
+}
+
+// GetExpiry is like the function GetExpiry, with the current time taken
+// from g.Now.
+func (g Getter) GetExpiry(cfg map[string]string, key string) (time.Time, error) {
+	value, err := lookup(cfg, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, ok := parseExpiry(value, g.now())
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w for %s: %q", ErrInvalidDate, key, value)
+	}
+	return t, nil
+}
+
+func parseExpiry(value string, ref time.Time) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "never", "false":
+		return Never, true
+	case "now", "all":
+		return ref, true
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return parseRelativeDate(value, ref)
+}
+
+func parseRelativeDate(value string, ref time.Time) (time.Time, bool) {
+	words := strings.FieldsFunc(strings.ToLower(value), func(c rune) bool {
+		return c == '.' || isspace(c)
+	})
+	if len(words) > 0 && words[len(words)-1] == "ago" {
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 || len(words)%2 != 0 {
+		return time.Time{}, false
+	}
+	t := ref
+	for i := 0; i < len(words); i += 2 {
+		n, err := strconv.Atoi(words[i])
+		if err != nil || n < 0 {
+			return time.Time{}, false
+		}
+		sub, ok := dateUnits[strings.TrimSuffix(words[i+1], "s")]
+		if !ok {
+			return time.Time{}, false
+		}
+		t = sub(t, n)
+	}
+	return t, true
+}
@@ -0,0 +1,54 @@
+package goconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetExpiry(t *testing.T) {
+	fixed := time.Date(2020, time.March, 15, 12, 0, 0, 0, time.UTC)
+	g := Getter{Now: func() time.Time { return fixed }}
+
+	tests := map[string]time.Time{
+		"never":                     Never,
+		"false":                     Never,
+		"now":                       fixed,
+		"90.days.ago":               fixed.AddDate(0, 0, -90),
+		"90 days":                   fixed.AddDate(0, 0, -90),
+		"2.weeks":                   fixed.AddDate(0, 0, -14),
+		"1.week.ago":                fixed.AddDate(0, 0, -7),
+		"1 year 6 months ago":       fixed.AddDate(-1, -6, 0),
+		"30.minutes.ago":            fixed.Add(-30 * time.Minute),
+		"2019-12-31":                time.Date(2019, time.December, 31, 0, 0, 0, 0, time.UTC),
+		"2019-12-31 10:20:30":       time.Date(2019, time.December, 31, 10, 20, 30, 0, time.UTC),
+		"2019-12-31T10:20:30+02:00": time.Date(2019, time.December, 31, 8, 20, 30, 0, time.UTC),
+	}
+	for value, expected := range tests {
+		expiry, err := g.GetExpiry(map[string]string{"gc.reflogexpire": value}, "gc.reflogexpire")
+		assert.Equal(t, nil, err, value)
+		assert.True(t, expected.Equal(expiry), "%s: %v != %v", value, expected, expiry)
+	}
+
+	expiry, _ := GetExpiry(map[string]string{"gc.reflogexpire": "never"}, "gc.reflogexpire")
+	assert.True(t, expiry.IsZero())
+
+	// The zero Getter and the function use the real clock.
+	before := time.Now()
+	expiry, err := GetExpiry(map[string]string{"gc.reflogexpire": "now"}, "gc.reflogexpire")
+	assert.Equal(t, nil, err)
+	assert.False(t, expiry.Before(before))
+	expiry, err = Getter{}.GetExpiry(map[string]string{"gc.reflogexpire": "1.day.ago"}, "gc.reflogexpire")
+	assert.Equal(t, nil, err)
+	assert.False(t, expiry.Before(before.AddDate(0, 0, -1)))
+}
+
+func TestGetExpiryInvalid(t *testing.T) {
+	for _, value := range []string{"", "soon", "90", "90.parsecs.ago", "-1.day", "days.90"} {
+		_, err := GetExpiry(map[string]string{"gc.pruneexpire": value}, "gc.pruneexpire")
+		assert.ErrorIs(t, err, ErrInvalidDate, value)
+	}
+	_, err := GetExpiry(map[string]string{}, "gc.pruneexpire")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
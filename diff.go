@@ -0,0 +1,113 @@
+package goconfig
+
+// Diff compares two configurations as returned by Parse. added holds the
+// keys that are only in new, removed the keys that are only in old (with
+// their old value), and changed the keys set in both with a different
+// value (with their new value). Keys are compared exactly, so both maps
+// should come from the parser or be normalized alike. The result maps are
+// never nil.
+func Diff(old, new map[string]string) (added, removed, changed map[string]string) {
+	added, removed, changed = map[string]string{}, map[string]string{}, map[string]string{}
+	for key, value := range new {
+		prev, ok := old[key]
+		switch {
+		case !ok:
+			added[key] = value
+		case prev != value:
+			changed[key] = value
+		}
+	}
+	for key, value := range old {
+		if _, ok := new[key]; !ok {
+			removed[key] = value
+		}
+	}
+	return added, removed, changed
+}
+
+// DiffMulti is like Diff for configurations as returned by ParseMulti. A
+// key is changed if its list of values differs in length, content or
+// order.
+func DiffMulti(old, new map[string][]string) (added, removed, changed map[string][]string) {
+	added, removed, changed = map[string][]string{}, map[string][]string{}, map[string][]string{}
+	for key, values := range new {
+		prev, ok := old[key]
+		switch {
+		case !ok:
+			added[key] = values
+		case !equalValues(prev, values):
+			changed[key] = values
+		}
+	}
+	for key, values := range old {
+		if _, ok := new[key]; !ok {
+			removed[key] = values
+		}
+	}
+	return added, removed, changed
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// SemanticDiff parses a and b with opts and compares the results with
+// Diff, so layout, comments, quoting, escapes and the spelling of section
+// and key names do not matter, only the values git reads. Since git reads
+// a key without '=' as true but "key =" as false, both are parsed with
+// BareKeysAsTrue. A key set more than once counts with its last value;
+// use SemanticDiffMulti to compare every value. Parse errors in either
+// input are returned as is.
+func SemanticDiff(a, b []byte, opts ...Option) (added, removed, changed map[string]string, err error) {
+	opts = append([]Option{BareKeysAsTrue()}, opts...)
+	old, _, err := Parse(a, opts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	new, _, err := Parse(b, opts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	added, removed, changed = Diff(old, new)
+	return added, removed, changed, nil
+}
+
+// SemanticDiffMulti is like SemanticDiff, but parses the inputs with
+// ParseMulti and compares them with DiffMulti, so every value of a key
+// counts, in order.
+func SemanticDiffMulti(a, b []byte, opts ...Option) (added, removed, changed map[string][]string, err error) {
+	opts = append([]Option{BareKeysAsTrue()}, opts...)
+	old, _, err := ParseMulti(a, opts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	new, _, err := ParseMulti(b, opts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	added, removed, changed = DiffMulti(old, new)
+	return added, removed, changed, nil
+}
+
+// SemanticEqual reports whether a and b hold the same configuration, as
+// defined by SemanticDiff, e.g. to check that a reformatted file still
+// means the same. Use SemanticDiff to see what differs.
+func SemanticEqual(a, b []byte, opts ...Option) (bool, error) {
+	added, removed, changed, err := SemanticDiff(a, b, opts...)
+	return err == nil && len(added)+len(removed)+len(changed) == 0, err
+}
+
+// SemanticEqualMulti is like SemanticEqual, but compares every value of a
+// key as SemanticDiffMulti does.
+func SemanticEqualMulti(a, b []byte, opts ...Option) (bool, error) {
+	added, removed, changed, err := SemanticDiffMulti(a, b, opts...)
+	return err == nil && len(added)+len(removed)+len(changed) == 0, err
+}
@@ -0,0 +1,123 @@
+package goconfig
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	old, _, err := ParseString("[user]\n\tname = Old\n\temail = a@example.com\n[core]\n\tbare\n\tpager = less\n")
+	assert.Equal(t, nil, err)
+	new, _, err := ParseString("[user]\n\tname = New\n\temail = a@example.com\n[core]\n\tbare =\n\teditor = vim\n")
+	assert.Equal(t, nil, err)
+
+	added, removed, changed := Diff(old, new)
+	assert.Equal(t, map[string]string{"core.editor": "vim"}, added)
+	assert.Equal(t, map[string]string{"core.pager": "less"}, removed)
+	assert.Equal(t, map[string]string{"user.name": "New"}, changed)
+
+	added, removed, changed = Diff(new, new)
+	assert.Equal(t, map[string]string{}, added)
+	assert.Equal(t, map[string]string{}, removed)
+	assert.Equal(t, map[string]string{}, changed)
+
+	added, removed, _ = Diff(nil, old)
+	assert.Equal(t, old, added)
+	assert.Equal(t, map[string]string{}, removed)
+}
+
+func TestDiffMulti(t *testing.T) {
+	old := map[string][]string{
+		"remote.origin.fetch": {"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"},
+		"core.editor":         {"vi"},
+		"core.pager":          {"less"},
+	}
+	new := map[string][]string{
+		"remote.origin.fetch": {"+refs/tags/*:refs/tags/*", "+refs/heads/*:refs/remotes/origin/*"},
+		"core.editor":         {"vi"},
+		"user.name":           {"A", "B"},
+	}
+	added, removed, changed := DiffMulti(old, new)
+	assert.Equal(t, map[string][]string{"user.name": {"A", "B"}}, added)
+	assert.Equal(t, map[string][]string{"core.pager": {"less"}}, removed)
+	assert.Equal(t, map[string][]string{"remote.origin.fetch": new["remote.origin.fetch"]}, changed)
+
+	added, removed, changed = DiffMulti(new, new)
+	assert.Equal(t, map[string][]string{}, added)
+	assert.Equal(t, map[string][]string{}, removed)
+	assert.Equal(t, map[string][]string{}, changed)
+}
+
+func TestSemanticEqual(t *testing.T) {
+	original := []byte(`# global settings
+[Core]
+	editor=vim   ; inline comment
+	bare
+[remote "origin"]
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	fetch = +refs/tags/*:refs/tags/*
+[alias] lg = "log --oneline" \
+ --graph
+`)
+	reformatted, err := Format(original)
+	assert.Equal(t, nil, err)
+	rewritten := []byte(`[alias]
+	lg = "log --oneline  --graph"
+[core]
+	bare = true
+	EDITOR = "vim"
+[remote "origin"]
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	fetch = +refs/tags/*:refs/tags/*
+`)
+	for _, other := range [][]byte{original, reformatted, rewritten} {
+		equal, err := SemanticEqual(original, other)
+		assert.Equal(t, nil, err)
+		assert.True(t, equal, string(other))
+		equal, err = SemanticEqualMulti(original, other)
+		assert.Equal(t, nil, err)
+		assert.True(t, equal, string(other))
+	}
+
+	// A bare key is true, "key =" is false.
+	emptied := bytes.Replace(rewritten, []byte("bare = true"), []byte("bare ="), 1)
+	equal, err := SemanticEqual(original, emptied)
+	assert.Equal(t, nil, err)
+	assert.False(t, equal)
+	added, removed, changed, err := SemanticDiff(original, emptied)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{}, added)
+	assert.Equal(t, map[string]string{}, removed)
+	assert.Equal(t, map[string]string{"core.bare": ""}, changed)
+
+	// Only the multi-valued comparison sees a dropped or reordered value.
+	dropped := bytes.Replace(rewritten, []byte("\tfetch = +refs/heads/*:refs/remotes/origin/*\n"), nil, 1)
+	equal, err = SemanticEqual(original, dropped)
+	assert.Equal(t, nil, err)
+	assert.True(t, equal)
+	equal, err = SemanticEqualMulti(original, dropped)
+	assert.Equal(t, nil, err)
+	assert.False(t, equal)
+	addedMulti, removedMulti, changedMulti, err := SemanticDiffMulti(original, dropped)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string][]string{}, addedMulti)
+	assert.Equal(t, map[string][]string{}, removedMulti)
+	assert.Equal(t, map[string][]string{
+		"remote.origin.fetch": {"+refs/tags/*:refs/tags/*"},
+	}, changedMulti)
+
+	// Options apply to both inputs.
+	equal, err = SemanticEqual([]byte("[core]\n\tEditor = vi\n"), []byte("[core]\n\teditor = vi\n"), KeepCase())
+	assert.Equal(t, nil, err)
+	assert.False(t, equal)
+
+	equal, err = SemanticEqual(original, []byte("[core\n"))
+	assert.False(t, equal)
+	var perr *ParseError
+	assert.True(t, errors.As(err, &perr))
+	_, err = SemanticEqualMulti([]byte("[core\n"), original)
+	assert.True(t, errors.As(err, &perr))
+}
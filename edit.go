@@ -0,0 +1,85 @@
+package goconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Set stores value under key in cfg. The key is normalized and validated
+// by NormalizeKey; if it is invalid, Set returns the error and leaves cfg
+// unchanged.
+func Set(cfg map[string]string, key, value string) error {
+	key, err := NormalizeKey(key)
+	if err != nil {
+		return err
+	}
+	cfg[key] = value
+	return nil
+}
+
+// Unset removes key, normalized as in Get, from cfg. It reports whether
+// the key was set.
+func Unset(cfg map[string]string, key string) bool {
+	key = normalizeKey(key)
+	_, ok := cfg[key]
+	delete(cfg, key)
+	return ok
+}
+
+// RemoveSection deletes every key of the given section and subsection, as
+// git config --remove-section does, and returns how many were removed. An
+// empty subsection removes the keys outside any subsection, leaving
+// [section "subsection"] blocks alone. section is matched
+// case-insensitively, subsection exactly.
+func RemoveSection(cfg map[string]string, section, subsection string) int {
+	section = strings.ToLower(section)
+	removed := 0
+	for key := range cfg {
+		if s, sub, _ := SplitKey(key); s == section && sub == subsection {
+			delete(cfg, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// RenameSection moves every key of [section "oldSub"] to [section "newSub"],
+// as git remote rename does, and returns how many keys were moved. The
+// subsections keep their case exactly as given. If the destination already
+// has keys, RenameSection returns ErrSectionExists and changes nothing,
+// unless overwrite is true, in which case the destination is replaced.
+func RenameSection(cfg map[string]string, section, oldSub, newSub string, overwrite bool) (int, error) {
+	section = strings.ToLower(section)
+	if strings.ContainsAny(newSub, "\r\n") {
+		return 0, ErrSectionNewLine
+	}
+	moved := map[string]string{}
+	exists := false
+	for key, value := range cfg {
+		s, sub, name := SplitKey(key)
+		if s != section {
+			continue
+		}
+		switch sub {
+		case oldSub:
+			moved[name] = value
+		case newSub:
+			exists = true
+		}
+	}
+	if oldSub == newSub || len(moved) == 0 {
+		return len(moved), nil
+	}
+	if exists {
+		if !overwrite {
+			return 0, fmt.Errorf("%w: %s", ErrSectionExists, strings.TrimSuffix(joinSection(section, newSub), "."))
+		}
+		RemoveSection(cfg, section, newSub)
+	}
+	RemoveSection(cfg, section, oldSub)
+	prefix := joinSection(section, newSub)
+	for name, value := range moved {
+		cfg[prefix+name] = value
+	}
+	return len(moved), nil
+}
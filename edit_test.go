@@ -0,0 +1,110 @@
+package goconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSet(t *testing.T) {
+	config := map[string]string{}
+	assert.Equal(t, nil, Set(config, "User.Name", "Danyel"))
+	assert.Equal(t, nil, Set(config, "remote.Origin.URL", "https://example.com/repo.git"))
+	assert.Equal(t, nil, Set(config, "user.name", "Danyel Bayraktar"))
+	assert.Equal(t, map[string]string{
+		"user.name":         "Danyel Bayraktar",
+		"remote.Origin.url": "https://example.com/repo.git",
+	}, config)
+
+	parsed, _, err := Parse([]byte("[user]\n\tname = Danyel Bayraktar\n[remote \"Origin\"]\n\tURL = https://example.com/repo.git"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, parsed, config)
+}
+
+func TestSetInvalid(t *testing.T) {
+	config := map[string]string{}
+	assert.ErrorIs(t, Set(config, "us_er.name", "x"), ErrInvalidSectionChar)
+	assert.ErrorIs(t, Set(config, "user.na_me", "x"), ErrInvalidKeyChar)
+	assert.ErrorIs(t, Set(config, "user.1name", "x"), ErrInvalidKeyChar)
+	assert.ErrorIs(t, Set(config, "name", "x"), ErrInvalidKeyChar)
+	assert.ErrorIs(t, Set(config, "remote.a\nb.url", "x"), ErrSectionNewLine)
+	assert.ErrorIs(t, Set(config, "remote.a\rb.url", "x"), ErrSectionNewLine)
+	assert.Equal(t, map[string]string{}, config)
+}
+
+func TestUnset(t *testing.T) {
+	config := map[string]string{"user.name": "Danyel", "remote.Origin.url": "x"}
+	assert.True(t, Unset(config, "User.Name"))
+	assert.False(t, Unset(config, "user.name"))
+	assert.False(t, Unset(config, "remote.origin.url"))
+	assert.True(t, Unset(config, "remote.Origin.URL"))
+	assert.Equal(t, map[string]string{}, config)
+}
+
+func TestRemoveSection(t *testing.T) {
+	config := map[string]string{
+		"remote.origin.url":      "o",
+		"remote.origin.fetch":    "f",
+		"remote.origin2.url":     "o2",
+		"remote.Origin.url":      "O",
+		"remote.pushdefault":     "origin",
+		"remotes.origin.url":     "r",
+		"user.name":              "Danyel",
+		"url.a.origin.insteadof": "x",
+	}
+	assert.Equal(t, 2, RemoveSection(config, "Remote", "origin"))
+	assert.Equal(t, map[string]string{
+		"remote.origin2.url":     "o2",
+		"remote.Origin.url":      "O",
+		"remote.pushdefault":     "origin",
+		"remotes.origin.url":     "r",
+		"user.name":              "Danyel",
+		"url.a.origin.insteadof": "x",
+	}, config)
+
+	assert.Equal(t, 1, RemoveSection(config, "remote", ""))
+	assert.Equal(t, "O", config["remote.Origin.url"])
+	assert.Equal(t, 0, RemoveSection(config, "core", ""))
+	assert.Equal(t, 0, RemoveSection(config, "url", "a"))
+}
+
+func TestRenameSection(t *testing.T) {
+	config := map[string]string{
+		"remote.origin.url":   "o",
+		"remote.origin.fetch": "f",
+		"remote.other.url":    "x",
+		"branch.main.remote":  "origin",
+	}
+	n, err := RenameSection(config, "remote", "origin", "Upstream", false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, map[string]string{
+		"remote.Upstream.url":   "o",
+		"remote.Upstream.fetch": "f",
+		"remote.other.url":      "x",
+		"branch.main.remote":    "origin",
+	}, config)
+
+	n, err = RenameSection(config, "remote", "Upstream", "other", false)
+	assert.ErrorIs(t, err, ErrSectionExists)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, "o", config["remote.Upstream.url"])
+
+	n, err = RenameSection(config, "Remote", "Upstream", "other", true)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, map[string]string{
+		"remote.other.url":   "o",
+		"remote.other.fetch": "f",
+		"branch.main.remote": "origin",
+	}, config)
+
+	n, err = RenameSection(config, "remote", "missing", "new", false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, n)
+
+	_, err = RenameSection(config, "remote", "other", "a\nb", false)
+	assert.ErrorIs(t, err, ErrSectionNewLine)
+	_, err = RenameSection(config, "remote", "other", "a\rb", false)
+	assert.ErrorIs(t, err, ErrSectionNewLine)
+}
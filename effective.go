@@ -0,0 +1,156 @@
+package goconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EffectiveOptions describes the config files and the repository that
+// Effective reads.
+type EffectiveOptions struct {
+	// System, Global and Local are the config files of each scope, e.g.
+	// /etc/gitconfig, ~/.gitconfig and .git/config. Global may name
+	// several files, read in order, like git reads
+	// $XDG_CONFIG_HOME/git/config before ~/.gitconfig. Empty paths and
+	// files that do not exist are skipped.
+	System string
+	Global []string
+	// Local defaults to the config file of the repository at GitDir. For a
+	// linked worktree that is the config of the main repository.
+	Local string
+	// Dir is the current directory, against which relative paths are
+	// resolved. It defaults to the working directory of the process.
+	Dir string
+	// GitDir is the .git directory of the repository, matched by gitdir:
+	// conditions. If it is empty, it is found like git does: in Dir or the
+	// nearest parent with a .git directory, or a .git file that points to
+	// one as in linked worktrees and submodules.
+	GitDir string
+	// Branch is the checked out branch matched by onbranch: conditions. It
+	// defaults to the branch HEAD in GitDir refers to, if any.
+	Branch string
+	// Options are passed on to the parser, e.g. MaxIncludeDepth.
+	Options []Option
+}
+
+// Effective returns the configuration git uses in the repository described
+// by opts: the system, global and local files are layered as in
+// ParseLayers, and each of them follows include.path and includeIf
+// directives as in ParseWithIncludes, with the includeIf conditions
+// evaluated against GitDir and Branch. Environment variables such as
+// GIT_CONFIG_GLOBAL or GIT_CONFIG_COUNT are not read; pass the paths they
+// name instead.
+//
+// Errors are those of ParseLayers. A .git file or HEAD that cannot be read
+// is not an error, the repository is then treated as unknown or detached.
+func Effective(opts EffectiveOptions) (map[string]string, error) {
+	dir := opts.Dir
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("goconfig: %w", err)
+		}
+		dir = wd
+	}
+	abs := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+
+	gitDir := abs(opts.GitDir)
+	if gitDir == "" {
+		gitDir = findGitDir(dir)
+	}
+	local, branch := abs(opts.Local), opts.Branch
+	if gitDir != "" {
+		if local == "" {
+			local = filepath.Join(commonDir(gitDir), "config")
+		}
+		if branch == "" {
+			branch = headBranch(gitDir)
+		}
+	}
+
+	paths := []string{abs(opts.System)}
+	for _, path := range opts.Global {
+		paths = append(paths, abs(path))
+	}
+	paths = append(paths, local)
+	o := newOptions(opts.Options)
+	inc := &includer{
+		opts:     opts.Options,
+		maxDepth: o.maxIncludeDepth,
+		ctx:      IncludeContext{GitDir: gitDir, Branch: branch},
+	}
+	return inc.parseLayers(paths)
+}
+
+// findGitDir returns the .git directory of the repository that contains
+// dir, or "" if there is none.
+func findGitDir(dir string) string {
+	for {
+		path := filepath.Join(dir, ".git")
+		if info, err := os.Stat(path); err == nil {
+			if info.IsDir() {
+				return path
+			}
+			if gitDir, ok := readGitFile(path); ok {
+				return gitDir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// readGitFile returns the directory a .git file of the form
+// "gitdir: <path>" points to.
+func readGitFile(path string) (string, bool) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(b)), "gitdir: ")
+	if !ok || gitDir == "" {
+		return "", false
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return filepath.Clean(gitDir), true
+}
+
+// commonDir returns the directory that holds the config of the repository
+// at gitDir, which differs from gitDir in linked worktrees.
+func commonDir(gitDir string) string {
+	b, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	common := strings.TrimSpace(string(b))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gitDir, common)
+	}
+	return filepath.Clean(common)
+}
+
+// headBranch returns the branch HEAD in gitDir refers to, or "" if HEAD is
+// detached or cannot be read.
+func headBranch(gitDir string) string {
+	b, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	branch, ok := strings.CutPrefix(strings.TrimSpace(string(b)), "ref: refs/heads/")
+	if !ok {
+		return ""
+	}
+	return branch
+}
@@ -0,0 +1,105 @@
+package goconfig
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffective(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"etc/gitconfig":           "[core]\n\teditor = vi\n\tpager = less\n[user]\n\tname = System\n",
+		"home/.config/git/config": "[user]\n\tname = XDG\n",
+		"home/.gitconfig": `[user]
+	email = private@example.com
+[include]
+	path = .gitconfig.d/common
+[includeIf "gitdir:~/work/"]
+	path = .gitconfig.d/work
+[includeIf "onbranch:release/*"]
+	path = .gitconfig.d/release
+`,
+		"home/.gitconfig.d/common":  "[core]\n\tpager = more\n",
+		"home/.gitconfig.d/work":    "[user]\n\temail = work@example.com\n",
+		"home/.gitconfig.d/release": "[push]\n\tdefault = current\n",
+
+		"home/work/repo/.git/HEAD":   "ref: refs/heads/main\n",
+		"home/work/repo/.git/config": "[core]\n\tbare = false\n[user]\n\tname = Local\n",
+		"home/work/repo/src/main.go": "",
+
+		"home/work/repo/.git/worktrees/wt/HEAD":      "ref: refs/heads/release/1.0\n",
+		"home/work/repo/.git/worktrees/wt/commondir": "../..\n",
+		"home/wt/.git": "gitdir: ../work/repo/.git/worktrees/wt\n",
+
+		"home/oss/.git/HEAD": "4b825dc642cb6eb9a060e54bf8d69288fbee4904\n",
+	})
+	home := filepath.Join(dir, "home")
+	t.Setenv("HOME", home)
+	opts := EffectiveOptions{
+		System: filepath.Join(dir, "etc/gitconfig"),
+		Global: []string{filepath.Join(home, ".config/git/config"), filepath.Join(home, ".gitconfig")},
+	}
+	base := map[string]string{
+		"core.editor":                       "vi",
+		"core.pager":                        "more",
+		"user.name":                         "XDG",
+		"user.email":                        "private@example.com",
+		"include.path":                      ".gitconfig.d/common",
+		"includeif.gitdir:~/work/.path":     ".gitconfig.d/work",
+		"includeif.onbranch:release/*.path": ".gitconfig.d/release",
+	}
+	with := func(extra map[string]string) map[string]string {
+		return Merge(base, extra)
+	}
+
+	tests := []struct {
+		name     string
+		opts     EffectiveOptions
+		expected map[string]string
+	}{
+		{"outside a repository", EffectiveOptions{Dir: home}, base},
+		{"repository found from a subdirectory", EffectiveOptions{Dir: filepath.Join(home, "work/repo/src")},
+			with(map[string]string{"user.email": "work@example.com", "core.bare": "false", "user.name": "Local"})},
+		{"linked worktree", EffectiveOptions{Dir: filepath.Join(home, "wt")},
+			with(map[string]string{
+				"user.email":   "work@example.com",
+				"push.default": "current",
+				"core.bare":    "false",
+				"user.name":    "Local",
+			})},
+		{"detached HEAD", EffectiveOptions{Dir: filepath.Join(home, "oss")}, base},
+		{"explicit branch", EffectiveOptions{Dir: filepath.Join(home, "oss"), Branch: "release/2.0"},
+			with(map[string]string{"push.default": "current"})},
+		{"explicit git dir and local", EffectiveOptions{Dir: home, GitDir: "work/repo/.git", Local: "wt/missing"},
+			with(map[string]string{"user.email": "work@example.com"})},
+	}
+	for _, test := range tests {
+		test.opts.System, test.opts.Global = opts.System, opts.Global
+		config, err := Effective(test.opts)
+		assert.Equal(t, nil, err, test.name)
+		assert.Equal(t, test.expected, config, test.name)
+	}
+
+	config, err := Effective(EffectiveOptions{Dir: home})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{}, config)
+
+	_, err = Effective(EffectiveOptions{
+		Dir:     filepath.Join(home, "work/repo"),
+		Global:  opts.Global,
+		Options: []Option{MaxIncludeDepth(0)},
+	})
+	assert.ErrorIs(t, err, ErrIncludeDepth)
+}
+
+func TestEffectiveErrors(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"repo/.git/config": "[core\n",
+	})
+	_, err := Effective(EffectiveOptions{Dir: filepath.Join(dir, "repo")})
+	var perr *ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Contains(t, err.Error(), filepath.Join(dir, "repo/.git/config"))
+}
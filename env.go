@@ -0,0 +1,117 @@
+package goconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvOption changes how ExpandEnv expands variables.
+type EnvOption func(*envOptions)
+
+type envOptions struct {
+	lookup func(string) (string, bool)
+	strict bool
+}
+
+// WithLookupEnv makes ExpandEnv read variables with lookup instead of
+// os.LookupEnv. lookup reports whether the variable is set.
+func WithLookupEnv(lookup func(name string) (string, bool)) EnvOption {
+	return func(o *envOptions) {
+		o.lookup = lookup
+	}
+}
+
+// StrictEnv makes ExpandEnv fail with ErrUndefinedVariable on a variable
+// that is not set, instead of expanding it to the empty string.
+func StrictEnv() EnvOption {
+	return func(o *envOptions) {
+		o.strict = true
+	}
+}
+
+// ExpandEnv returns a copy of cfg with environment variables in the values
+// expanded: ${VAR} and $VAR are replaced by the value of VAR, and $$ by a
+// single $. A variable name is made of letters, digits and underscores and
+// does not start with a digit; a $ that does not start a reference is kept.
+// Unset variables expand to the empty string unless StrictEnv is given.
+//
+// This is an extension: git itself never expands variables in values, so
+// only call ExpandEnv for configurations written with it in mind. cfg is
+// not modified.
+func ExpandEnv(cfg map[string]string, opts ...EnvOption) (map[string]string, error) {
+	o := envOptions{lookup: os.LookupEnv}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	expanded := make(map[string]string, len(cfg))
+	for key, value := range cfg {
+		value, missing := expandEnv(value, &o)
+		if missing != "" {
+			return nil, fmt.Errorf("%w for %s: %s", ErrUndefinedVariable, key, missing)
+		}
+		expanded[key] = value
+	}
+	return expanded, nil
+}
+
+// expandEnv expands the variables in value. In strict mode it stops at
+// the first variable that is not set and returns its name.
+func expandEnv(value string, o *envOptions) (expanded, missing string) {
+	if !strings.Contains(value, "$") {
+		return value, ""
+	}
+	var buf strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			buf.WriteByte(value[i])
+			continue
+		}
+		var name string
+		rest := value[i+1:]
+		switch {
+		case rest[0] == '$':
+			buf.WriteByte('$')
+			i++
+			continue
+		case rest[0] == '{':
+			end := strings.IndexByte(rest, '}')
+			if end < 0 || !validEnvName(rest[1:end]) {
+				buf.WriteByte('$')
+				continue
+			}
+			name = rest[1:end]
+			i += end + 1
+		default:
+			n := 0
+			for n < len(rest) && isEnvChar(rest[n], n == 0) {
+				n++
+			}
+			if n == 0 {
+				buf.WriteByte('$')
+				continue
+			}
+			name = rest[:n]
+			i += n
+		}
+		env, ok := o.lookup(name)
+		if !ok && o.strict {
+			return "", name
+		}
+		buf.WriteString(env)
+	}
+	return buf.String(), ""
+}
+
+func validEnvName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if !isEnvChar(name[i], i == 0) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+func isEnvChar(c byte, first bool) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || !first && c >= '0' && c <= '9'
+}
@@ -0,0 +1,49 @@
+package goconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{"HOME": "/home/danyel", "USER": "danyel", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	cfg := map[string]string{
+		"core.excludesfile": "${HOME}/.gitignore",
+		"user.email":        "$USER@example.com",
+		"price.tag":         "$$5 and $$$USER",
+		"plain.value":       "no variables",
+		"odd.dollars":       "a $ b $1 ${ ${1x} $",
+		"set.empty":         "[$EMPTY]",
+		"unset.var":         "[$MISSING${MISSING}]",
+		"adjacent.vars":     "$USER$USER_x${USER}_x",
+	}
+	expanded, err := ExpandEnv(cfg, WithLookupEnv(lookup))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"core.excludesfile": "/home/danyel/.gitignore",
+		"user.email":        "danyel@example.com",
+		"price.tag":         "$5 and $danyel",
+		"plain.value":       "no variables",
+		"odd.dollars":       "a $ b $1 ${ ${1x} $",
+		"set.empty":         "[]",
+		"unset.var":         "[]",
+		"adjacent.vars":     "danyeldanyel_x",
+	}, expanded)
+	assert.Equal(t, "${HOME}/.gitignore", cfg["core.excludesfile"])
+
+	_, err = ExpandEnv(map[string]string{"unset.var": "$MISSING"}, WithLookupEnv(lookup), StrictEnv())
+	assert.ErrorIs(t, err, ErrUndefinedVariable)
+	assert.Equal(t, "undefined variable for unset.var: MISSING", err.Error())
+	_, err = ExpandEnv(map[string]string{"set.empty": "$EMPTY"}, WithLookupEnv(lookup), StrictEnv())
+	assert.Equal(t, nil, err)
+
+	t.Setenv("GOCONFIG_TEST_VAR", "from env")
+	expanded, err = ExpandEnv(map[string]string{"a.b": "${GOCONFIG_TEST_VAR}"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "from env", expanded["a.b"])
+}
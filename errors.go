@@ -1,6 +1,33 @@
 package goconfig
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
+
+// ParseError records the position at which parsing failed. Line and Column
+// are 1-based; Column points at the offending rune. Err is one of the
+// sentinel errors below, so errors.Is can be used to check the cause.
+// Detail, if not empty, adds context to Err, like the part of a section
+// header read before the end of the input.
+type ParseError struct {
+	Line   uint
+	Column uint
+	Err    error
+	Detail string
+}
+
+func (e *ParseError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("line %d, column %d: %v %s", e.Line, e.Column, e.Err, e.Detail)
+	}
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying sentinel error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
 
 // ErrInvalidEscapeSequence indicates that the escape character ('\')
 // was followed by an invalid character.
@@ -18,6 +45,9 @@ var ErrPartialBOM = errors.New("partial UTF8-BOM")
 // ErrInvalidKeyChar indicates that there was an invalid key character
 var ErrInvalidKeyChar = errors.New("invalid key character")
 
+// ErrEmptyKey indicates that a line starts with an equals sign ('=') instead of a key
+var ErrEmptyKey = errors.New("missing key before '='")
+
 // ErrInvalidSectionChar indicates that there was an invalid character in section
 var ErrInvalidSectionChar = errors.New("invalid character in section")
 
@@ -32,3 +62,77 @@ var ErrMissingStartQuote = errors.New("missing start quote")
 
 // ErrMissingClosingBracket indicates that there was a missing closing bracket in section
 var ErrMissingClosingBracket = errors.New("missing closing section bracket")
+
+// ErrKeyOutsideSection indicates that a variable is defined before the first section header
+var ErrKeyOutsideSection = errors.New("key does not contain a section")
+
+// ErrCarriageReturn indicates that a value to be written contains a carriage return, which would be read back as a line ending
+var ErrCarriageReturn = errors.New("carriage return in value")
+
+// ErrKeyNotFound indicates that a requested key is not set
+var ErrKeyNotFound = errors.New("key not found")
+
+// ErrInvalidBool indicates that a value is not a valid boolean
+var ErrInvalidBool = errors.New("invalid boolean value")
+
+// ErrInvalidInt indicates that a value is not a valid integer
+var ErrInvalidInt = errors.New("invalid integer value")
+
+// ErrOutOfRange indicates that an integer value does not fit the requested type
+var ErrOutOfRange = errors.New("integer value out of range")
+
+// ErrInvalidPath indicates that a path value could not be expanded
+var ErrInvalidPath = errors.New("cannot expand path")
+
+// ErrInvalidColor indicates that a color value contains an unknown word
+var ErrInvalidColor = errors.New("invalid color value")
+
+// ErrInvalidColorMode indicates that a value is neither a color mode (never, always, auto) nor a boolean
+var ErrInvalidColorMode = errors.New("invalid color mode")
+
+// ErrInvalidDate indicates that a value is not a valid expiry date
+var ErrInvalidDate = errors.New("invalid date value")
+
+// ErrIncludeCycle indicates that a file includes itself, directly or indirectly
+var ErrIncludeCycle = errors.New("include cycle")
+
+// ErrIncludeDepth indicates that includes are nested too deeply
+var ErrIncludeDepth = errors.New("exceeded maximum include depth")
+
+// ErrInvalidTarget indicates that Unmarshal was not given a non-nil pointer to a struct,
+// or MarshalStruct neither a struct nor a pointer to one
+var ErrInvalidTarget = errors.New("target must be a non-nil pointer to a struct")
+
+// ErrUnsupportedType indicates that a tagged struct field has a type that cannot be
+// decoded or encoded
+var ErrUnsupportedType = errors.New("unsupported field type")
+
+// ErrKeyConflict indicates that a variable and a subsection of the same section share a name
+var ErrKeyConflict = errors.New("variable and subsection share a name")
+
+// ErrLimitExceeded indicates that the input is larger than the configured limits
+var ErrLimitExceeded = errors.New("input limit exceeded")
+
+// ErrSectionExists indicates that the destination of a section rename already has keys
+var ErrSectionExists = errors.New("section already exists")
+
+// ErrDuplicateSection indicates that a section header appears more than once
+var ErrDuplicateSection = errors.New("duplicate section")
+
+// ErrLegacySectionSyntax indicates that a section header uses the deprecated [section.subsection] form
+var ErrLegacySectionSyntax = errors.New("legacy section syntax")
+
+// ErrUndefinedVariable indicates that a value refers to an environment variable that is not set
+var ErrUndefinedVariable = errors.New("undefined variable")
+
+// ErrInvalidUTF8 indicates that the input is not valid UTF-8
+var ErrInvalidUTF8 = errors.New("invalid UTF-8")
+
+// ErrAliasCycle indicates that a git alias expands to itself
+var ErrAliasCycle = errors.New("alias cycle")
+
+// ErrAliasDepth indicates that a chain of git aliases nests too deeply
+var ErrAliasDepth = errors.New("alias chain too deep")
+
+// ErrUnknownType indicates that GetTyped was asked for a type that is neither built in nor registered
+var ErrUnknownType = errors.New("unknown type")
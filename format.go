@@ -0,0 +1,186 @@
+package goconfig
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// Format parses b and returns it in canonical style, like gofmt does for
+// Go code: section headers are written as [section "subsection"] with a
+// lowercase section name, and each one is preceded by exactly one blank
+// line. Keys are indented with a tab and written as "key = value" with the
+// value quoted and escaped as by Marshal ("key =" if it is empty); key
+// names keep their spelling. Comments are kept in place, inline comments
+// on the line of their header or key; comment lines right above a header
+// stay with it. Runs of blank lines are collapsed to one, and blank lines
+// at the start and end of the file and right after a header are dropped.
+// Line endings become "\n". The result parses to the same values as b, and
+// formatting it again does not change it.
+func Format(b []byte) ([]byte, error) {
+	nodes, err := ParseNodes(b)
+	if err != nil {
+		return nil, err
+	}
+	f := &formatter{}
+	if bytes.HasPrefix(b, utf8BOM) {
+		f.out.Write(utf8BOM)
+		f.start = len(utf8BOM)
+	}
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *BlankNode:
+			f.blankLine()
+		case *CommentNode:
+			raw := strings.TrimPrefix(n.Text, "\xef\xbb\xbf")
+			text := strings.TrimSpace(raw)
+			if text == "" {
+				// A line holding only the byte order mark.
+				f.blankLine()
+				continue
+			}
+			if first, _ := utf8.DecodeRuneInString(raw); isspace(first) {
+				text = "\t" + text
+			}
+			f.comments = append(f.comments, text)
+		case *SectionNode:
+			f.blank = true
+			f.flush()
+			header, comment := splitHeader(n.Text)
+			quoted := strings.Contains(header, `"`)
+			if n.Section == "" || !quoted && (strings.Contains(header, "..") || strings.HasSuffix(header, ".]")) {
+				// Legacy headers with empty parts, like [a..b] or [a.],
+				// have no canonical form with the same keys.
+				f.out.WriteString(header)
+				f.out.WriteByte('\n')
+			} else {
+				writeHeader(&f.out, n.Section, n.Subsection, quoted || n.Subsection != "")
+			}
+			f.comment(comment)
+			f.header = true
+		case *KeyNode:
+			f.flush()
+			name, hasValue, comment := splitKeyText(n.Text)
+			f.out.WriteByte('\t')
+			f.out.WriteString(name)
+			if hasValue {
+				f.out.WriteString(" =")
+				if n.Value != "" {
+					f.out.WriteByte(' ')
+					writeValue(&f.out, n.Value)
+				}
+			}
+			f.out.WriteByte('\n')
+			f.comment(comment)
+			f.header = false
+		}
+	}
+	f.blank = f.blank && len(f.comments) > 0
+	f.flush()
+	return f.out.Bytes(), nil
+}
+
+type formatter struct {
+	out bytes.Buffer
+	// start is the length of the byte order mark written to out.
+	start int
+	// comments are the comment lines not written yet, blank is set if a
+	// blank line goes before them (or before the next line).
+	comments []string
+	blank    bool
+	// header is set right after a section header.
+	header bool
+}
+
+// blankLine records a blank line in the input.
+func (f *formatter) blankLine() {
+	if len(f.comments) > 0 {
+		f.flush()
+	}
+	if !f.header {
+		f.blank = true
+	}
+}
+
+// flush writes the pending blank line and comments.
+func (f *formatter) flush() {
+	if f.blank && f.out.Len() > f.start {
+		f.out.WriteByte('\n')
+	}
+	for _, c := range f.comments {
+		f.out.WriteString(c)
+		f.out.WriteByte('\n')
+	}
+	if len(f.comments) > 0 {
+		f.header = false
+	}
+	f.comments, f.blank = f.comments[:0], false
+}
+
+// comment appends an inline comment to the line just written.
+func (f *formatter) comment(comment string) {
+	if comment == "" {
+		return
+	}
+	f.out.Truncate(f.out.Len() - 1)
+	f.out.WriteByte(' ')
+	f.out.WriteString(comment)
+	f.out.WriteByte('\n')
+}
+
+// splitHeader splits the text of a SectionNode into the header and the
+// comment after it.
+func splitHeader(text string) (header, comment string) {
+	start := strings.IndexByte(text, '[')
+	quote := false
+	for i := start + 1; i < len(text); i++ {
+		switch {
+		case quote && text[i] == '\\':
+			i++
+		case text[i] == '"':
+			quote = !quote
+		case !quote && text[i] == ']':
+			return text[start : i+1], lineComment(text[i+1:])
+		}
+	}
+	return text[start:], ""
+}
+
+// splitKeyText returns the name of the variable in the text of a KeyNode
+// as written, whether it has a value, and its inline comment.
+func splitKeyText(text string) (name string, hasValue bool, comment string) {
+	text = strings.TrimLeftFunc(strings.TrimPrefix(text, "\xef\xbb\xbf"), isspace)
+	end := len(text)
+	for i, c := range text {
+		if !iskeychar(c) {
+			end = i
+			break
+		}
+	}
+	name, rest := text[:end], strings.TrimLeftFunc(text[end:], isspace)
+	if !strings.HasPrefix(rest, "=") {
+		return name, false, lineComment(rest)
+	}
+	quote := false
+	for i := 1; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case c == '\\':
+			i++
+		case c == '"':
+			quote = !quote
+		case !quote && (c == '#' || c == ';'):
+			return name, true, lineComment(rest[i:])
+		}
+	}
+	return name, true, ""
+}
+
+// lineComment returns the comment in the rest of a line, which is empty or
+// starts with whitespace and a comment.
+func lineComment(rest string) string {
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "#") || strings.HasPrefix(rest, ";") {
+		return rest
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package goconfig
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	input := "\n\n# global settings\r\n" +
+		"[User]   ; me\r\n" +
+		"\n" +
+		"name=Danyel  # inline\r\n" +
+		"\r\n" +
+		"\r\n" +
+		"      email   =   \"cydrop@gmail.com\"\r\n" +
+		"  # indented comment\n" +
+		"[remote \"origin\"] url = a \\\n" +
+		"  b\n" +
+		"\tfetch = \"x # y\" ; real comment\n" +
+		"# about core\n" +
+		"[core.Sub]\n" +
+		"\tbare\n" +
+		"\tempty =\n" +
+		"\tleading = \"  x\"\n" +
+		"[a \"\"]\n" +
+		"\tb = 1\n" +
+		"\n" +
+		"# trailing\n" +
+		"\n"
+	expected := "# global settings\n" +
+		"[user] ; me\n" +
+		"\tname = Danyel # inline\n" +
+		"\n" +
+		"\temail = cydrop@gmail.com\n" +
+		"\n" +
+		"\t# indented comment\n" +
+		"[remote \"origin\"]\n" +
+		"\turl = a   b\n" +
+		"\tfetch = \"x # y\" ; real comment\n" +
+		"\n" +
+		"# about core\n" +
+		"[core \"sub\"]\n" +
+		"\tbare\n" +
+		"\tempty =\n" +
+		"\tleading = \"  x\"\n" +
+		"\n" +
+		"[a \"\"]\n" +
+		"\tb = 1\n" +
+		"\n" +
+		"# trailing\n"
+	out, err := Format([]byte(input))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, expected, string(out))
+
+	again, err := Format(out)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, string(out), string(again))
+
+	before, _, err := Parse([]byte(input))
+	assert.Equal(t, nil, err)
+	after, _, err := Parse(out)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, before, after)
+
+	// A lone '\r' ends lines, so the comments are separate lines.
+	out, err = Format([]byte("# top comment\r[user]\r# about name\r\tname = x\r\r# about core\r[core]\r\tbare\r"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "# top comment\n"+
+		"[user]\n"+
+		"# about name\n"+
+		"\tname = x\n"+
+		"\n"+
+		"# about core\n"+
+		"[core]\n"+
+		"\tbare\n", string(out))
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	b, err := ioutil.ReadFile("configs/danyel.gitconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := []string{
+		string(b),
+		"",
+		"\n\n",
+		"# only a comment",
+		"\xef\xbb\xbf[core]\n\tbare\n",
+		"[a]\n\n\n\tb = c\n\n\n\td = e ; x\n",
+		"[a] b = \"q\\\"uo;te\" # c\n[b]\n[c]\n",
+		"[a..b]\n\tk = 1\n[a.]\n\tk = 2\n[.]\n\tk = 3\n",
+		"[a]\n\tb = \"x\u0085y\"\n\tc = \"\u00a0\fz\u2028\"\n",
+		"# top\r[a] ; x\r# c1\r\tb = c\r\r# c2\r[d]\r\te\r# end",
+	}
+	for _, input := range inputs {
+		out, err := Format([]byte(input))
+		assert.Equal(t, nil, err, input)
+		assert.Equal(t, commentLines(input), commentLines(string(out)), input)
+		again, err := Format(out)
+		assert.Equal(t, nil, err, input)
+		assert.Equal(t, string(out), string(again), input)
+		equal, err := SemanticEqualMulti([]byte(input), out)
+		assert.Equal(t, nil, err, input)
+		assert.True(t, equal, input)
+
+		before, _, _ := Parse([]byte(input))
+		after, _, err := Parse(out)
+		assert.Equal(t, nil, err, input)
+		assert.Equal(t, before, after, input)
+	}
+
+	out, err := Format([]byte("\xef\xbb\xbf\n[core]\n\n\tbare\n"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "\xef\xbb\xbf[core]\n\tbare\n", string(out))
+
+	_, err = Format([]byte("[core\n"))
+	assert.ErrorIs(t, err, ErrSectionNewLine)
+}
+
+// commentLines returns the comments of a config in order.
+func commentLines(input string) []string {
+	var comments []string
+	s := NewScanner([]byte(input))
+	for {
+		typ, text, _ := s.Scan()
+		switch typ {
+		case TokenComment:
+			comments = append(comments, text)
+		case TokenEOF, TokenError:
+			return comments
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package goconfig
+
+import (
+	"os"
+	"os/user"
+	"time"
+)
+
+// Getter holds the parts of the environment that some getters depend on,
+// so that tests and tools can supply their own. Its methods behave like
+// the functions of the same name, which use the zero Getter:
+//
+//	g := goconfig.Getter{
+//		Now:     func() time.Time { return time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC) },
+//		HomeDir: func(string) (string, error) { return "/home/test", nil },
+//	}
+//	path, err := g.GetPath(cfg, "core.excludesfile")
+type Getter struct {
+	// Now returns the current time, from which GetExpiry counts relative
+	// dates back. Nil means time.Now.
+	Now func() time.Time
+	// HomeDir returns the home directory of the named user, or of the
+	// current user if name is empty, for the ~ expansion of GetPath. Nil
+	// means $HOME (see os.UserHomeDir) for the current user and the
+	// system's user database for others.
+	HomeDir func(name string) (string, error)
+}
+
+func (g Getter) now() time.Time {
+	if g.Now != nil {
+		return g.Now()
+	}
+	return time.Now()
+}
+
+func (g Getter) homeDir(name string) (string, error) {
+	if g.HomeDir != nil {
+		return g.HomeDir(name)
+	}
+	return homeDir(name)
+}
+
+// homeDir returns the home directory of the named user, or of the current
+// user if name is empty.
+func homeDir(name string) (string, error) {
+	if name == "" {
+		return os.UserHomeDir()
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return "", err
+	}
+	return u.HomeDir, nil
+}
@@ -2,114 +2,689 @@
 package goconfig
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
 	"unicode"
+	"unicode/utf8"
+	"unsafe"
 )
 
 type parser struct {
-	runes  []rune
-	linenr uint
+	opts options
+	// The input is either the remaining bytes in buf, decoded on demand,
+	// or src if it is not nil.
+	buf []byte
+	src io.RuneScanner
+	// line and col are the position of the last rune read.
+	line uint
+	col  uint
+	// offset is the number of bytes read.
+	offset int64
+	eol    bool
 	eof    bool
+	err    error
+	// value is the buffer parseValue accumulates values in.
+	value []byte
+	// quoted reports whether the last value contained a quoted span.
+	quoted bool
+	// lead holds the whitespace before the last value if spacing is
+	// checked, and leadEOL is set if the line ended after it.
+	lead    []byte
+	leadEOL bool
+	// name is the buffer section and variable names are accumulated in.
+	name []byte
+	// bare reports whether the last key had no '='.
+	bare bool
+	// comment holds the comment at the end of the last value if
+	// keepComment is set.
+	comment     []byte
+	keepComment bool
+	// keyLine and keyOffset are the position of the first rune of the
+	// last key, section header or comment returned by step.
+	keyLine   uint
+	keyOffset int64
+	// section is the prefix of the variables in the current section, as
+	// in "remote.origin.", or "" before the first header.
+	section string
+	// skip is set after an invalid section header in lenient mode, so that
+	// its keys are not attributed to another section.
+	skip bool
+	// filtered is set in sections that are not allowed by WithSections.
+	filtered bool
+	// seen holds the section headers read so far if duplicates are
+	// rejected.
+	seen map[string]bool
+	// lenient makes parse record syntax errors in errs and continue.
+	lenient bool
+	errs    []ParseError
+	// onSection, if not nil, is called for every valid section header
+	// with the offset of its '['.
+	onSection func(name string, start int64)
+	// ctx, if not nil, is checked every ctxCheckInterval runes.
+	ctx   context.Context
+	ticks uint
 }
 
+// ctxCheckInterval is the number of runes ParseContext reads between two
+// checks of the context.
+const ctxCheckInterval = 4096
+
 // Parse takes given bytes as configuration file (according to gitconfig syntax)
-func Parse(bytes []byte) (map[string]string, uint, error) {
-	parser := &parser{[]rune(string(bytes)), 1, false}
-	cfg, err := parser.parse()
-	return cfg, parser.linenr, err
+//
+// Values may contain the escape sequences \n (newline), \t (tab),
+// \b (backspace), \\ (backslash) and \" (double quote), and with the
+// HexEscapes option \xNN, which git does not accept. Any other escape is an
+// ErrInvalidEscapeSequence; in particular there are no octal or \u escapes
+// as in shells or Go. Other control characters are taken as they are,
+// except that a carriage return always ends the line, for files with
+// classic Mac line endings. A backslash at the end of a line continues the
+// value on the next line, also inside quotes, for as many lines as needed;
+// the line count and error positions refer to the physical lines.
+//
+// Like in git, a section header may be followed on the same line by a
+// comment or by a variable: [core] editor = vi and [core]bare are valid,
+// and anything else after the ']' is an ErrInvalidKeyChar.
+//
+// Section and variable names are lowercased. A subsection keeps its case
+// in the quoted form [section "Sub"], but is lowercased in the deprecated
+// form [section.Sub], like git does: [url "GitHub"] sets url.GitHub.*,
+// while [url.GitHub] and [url.github] both set url.github.*.
+func Parse(b []byte, opts ...Option) (map[string]string, uint, error) {
+	cfg := map[string]string{}
+	lineno, err := parseBytes(b, func(name, key, value string) error {
+		cfg[name+key] = value
+		return nil
+	}, opts)
+	return cfg, lineno, err
 }
 
-func (cf *parser) parse() (map[string]string, error) {
-	comment := false
+// ParseContext parses the given bytes like Parse, but stops early if ctx
+// is canceled or its deadline passes. In that case it returns a nil map
+// and ctx.Err(), unwrapped.
+func ParseContext(ctx context.Context, b []byte, opts ...Option) (map[string]string, uint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
 	cfg := map[string]string{}
-	name := ""
-	var err error
+	cf, err := newBytesParser(b, opts)
+	if err != nil {
+		return cfg, 1, err
+	}
+	cf.ctx = ctx
+	lineno, err := cf.run(func(name, key, value string) error {
+		cfg[name+key] = value
+		return nil
+	})
+	if err != nil && err == ctx.Err() {
+		return nil, lineno, err
+	}
+	return cfg, lineno, err
+}
+
+// ParseMulti works like Parse, but keeps every value of a key that is set
+// more than once (e.g. remote.origin.fetch), in the order they appear.
+func ParseMulti(b []byte, opts ...Option) (map[string][]string, uint, error) {
+	cfg := map[string][]string{}
+	lineno, err := parseBytes(b, func(name, key, value string) error {
+		cfg[name+key] = append(cfg[name+key], value)
+		return nil
+	}, opts)
+	return cfg, lineno, err
+}
+
+// Entry is a parsed value together with details about how it was written.
+type Entry struct {
+	Value string
+	// Quoted reports whether the value contained a quoted span, e.g.
+	// name = " x " or name = a" "b, as opposed to name = x.
+	Quoted bool
+	// Line is the line the key is defined on, and Offset the byte offset
+	// of the first rune of its name in the input.
+	Line   uint
+	Offset int64
+}
+
+// ParseString parses the configuration in s exactly like Parse.
+func ParseString(s string, opts ...Option) (map[string]string, uint, error) {
+	// The parser never writes to its input and keeps no reference to it
+	// once it returns, so s can be used without copying it.
+	return Parse(unsafe.Slice(unsafe.StringData(s), len(s)), opts...)
+}
+
+// ParseEntries parses the given bytes like Parse, but returns an Entry for
+// each key instead of the bare value. Like Parse, it keeps the last
+// definition of a key that is set more than once.
+func ParseEntries(b []byte, opts ...Option) (map[string]Entry, uint, error) {
+	cfg := map[string]Entry{}
+	cf, err := newBytesParser(b, opts)
+	if err != nil {
+		return cfg, 1, err
+	}
+	lineno, err := cf.run(func(name, key, value string) error {
+		cfg[name+key] = Entry{
+			Value:  value,
+			Quoted: cf.quoted,
+			Line:   cf.keyLine,
+			Offset: cf.keyOffset,
+		}
+		return nil
+	})
+	return cfg, lineno, err
+}
+
+// ParseLenient parses the given bytes like Parse, but does not stop at
+// syntax errors: each error is recorded with its position and parsing
+// resumes on the next line. Keys below an invalid section header are
+// dropped up to the next valid header. The returned map holds every
+// variable that was parsed successfully.
+func ParseLenient(b []byte, opts ...Option) (map[string]string, uint, []ParseError) {
+	cfg := map[string]string{}
+	var errs []ParseError
+	cf, err := newBytesParser(b, opts)
+	if err != nil {
+		errs = append(errs, *err.(*ParseError))
+	}
+	cf.lenient = true
+	lineno, err := cf.run(func(name, key, value string) error {
+		cfg[name+key] = value
+		return nil
+	})
+	errs = append(errs, cf.errs...)
+	if perr, ok := err.(*ParseError); ok {
+		errs = append(errs, *perr)
+	}
+	return cfg, lineno, errs
+}
+
+// Validate checks that the given bytes are valid gitconfig syntax without
+// building a map. It returns the first ParseError, or nil.
+func Validate(b []byte, opts ...Option) error {
+	_, err := parseBytes(b, func(name, key, value string) error {
+		return nil
+	}, opts)
+	return err
+}
+
+// ValidateFile reads the file at path and checks it like Validate. Errors
+// from reading the file are wrapped as in ParseFile.
+func ValidateFile(path string, opts ...Option) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("goconfig: %w", err)
+	}
+	return Validate(b, opts...)
+}
+
+// ParseFunc parses the given bytes like Parse, but instead of building a
+// map calls fn for every variable in file order. section and key are
+// lowercase, subsection keeps its case and is empty outside a
+// [section "subsection"] block. If fn returns an error, parsing stops and
+// that error is returned unchanged.
+func ParseFunc(b []byte, fn func(section, subsection, key, value string) error, opts ...Option) (uint, error) {
+	return parseBytes(b, func(name, key, value string) error {
+		section, subsection := splitSection(name)
+		return fn(section, subsection, key, value)
+	}, opts)
+}
+
+// ParseReader reads the configuration from r and parses it incrementally.
+// It behaves exactly like Parse on the same content, except that read
+// errors from r are returned as is.
+func ParseReader(r io.Reader, opts ...Option) (map[string]string, uint, error) {
+	cfg := map[string]string{}
+	br := bufio.NewReader(r)
+	// Peek errors are reported again by the first read.
+	prefix, _ := br.Peek(len(utf8BOM))
+	skip, err := checkBOM(prefix)
+	if err != nil {
+		return cfg, 1, err
+	}
+	_, _ = br.Discard(skip)
+	cf := newParser(nil, br, opts)
+	cf.offset = int64(skip)
+	lineno, err := cf.run(func(name, key, value string) error {
+		cfg[name+key] = value
+		return nil
+	})
+	return cfg, lineno, err
+}
+
+// ParseFile reads the file at path and parses it like Parse. Errors from
+// opening or reading the file are wrapped, so they can be told apart from
+// syntax errors with errors.Is (e.g. errors.Is(err, os.ErrNotExist)).
+func ParseFile(path string, opts ...Option) (map[string]string, uint, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("goconfig: %w", err)
+	}
+	return Parse(b, opts...)
+}
+
+// ParseFS reads the file name from fsys and parses it like ParseFile, e.g.
+// a default configuration embedded with //go:embed. Errors from fsys are
+// wrapped like those of ParseFile (e.g. errors.Is(err, fs.ErrNotExist)).
+func ParseFS(fsys fs.FS, name string, opts ...Option) (map[string]string, uint, error) {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("goconfig: %w", err)
+	}
+	return Parse(b, opts...)
+}
+
+// setter receives every parsed variable. name is the section prefix
+// including the trailing dot ("remote.origin."), key the variable name.
+// An error returned by a setter stops parsing and is returned unchanged.
+type setter func(name, key, value string) error
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// checkBOM returns the length of the UTF-8 byte order mark at the start of
+// prefix, or a ParseError if prefix starts with an incomplete one.
+func checkBOM(prefix []byte) (int, error) {
+	if bytes.HasPrefix(prefix, utf8BOM) {
+		return len(utf8BOM), nil
+	}
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+	if len(prefix) > 0 && bytes.HasPrefix(utf8BOM, prefix) {
+		return 0, &ParseError{Line: 1, Column: 1, Err: ErrPartialBOM}
+	}
+	return 0, nil
+}
+
+func parseBytes(b []byte, set setter, opts []Option) (uint, error) {
+	cf, err := newBytesParser(b, opts)
+	if err != nil {
+		return 1, err
+	}
+	return cf.run(set)
+}
+
+// newBytesParser returns a parser for b that skips its byte order mark.
+// Offsets count the mark, so that they refer to b. If b starts with a
+// partial mark, the error is returned together with a parser for all of b.
+func newBytesParser(b []byte, opts []Option) (*parser, error) {
+	skip, err := checkBOM(b)
+	cf := newParser(b[skip:], nil, opts)
+	cf.offset = int64(skip)
+	return cf, err
+}
+
+func newParser(buf []byte, src io.RuneScanner, opts []Option) *parser {
+	cf := &parser{opts: newOptions(opts), buf: buf, src: src, line: 1}
+	cf.filtered = cf.opts.sections != nil
+	if cf.opts.rejectDuplicates {
+		cf.seen = map[string]bool{}
+	}
+	return cf
+}
+
+func (cf *parser) run(set setter) (uint, error) {
+	if cf.opts.onComplete != nil {
+		return cf.runStats(set)
+	}
+	return cf.result(cf.parse(set))
+}
+
+// runStats is run for the OnComplete option.
+func (cf *parser) runStats(set setter) (uint, error) {
+	start := time.Now()
+	keys := 0
+	lineno, err := cf.result(cf.parse(func(name, key, value string) error {
+		keys++
+		return set(name, key, value)
+	}))
+	cf.opts.onComplete(ParseStats{
+		Lines:    lineno,
+		Keys:     keys,
+		Bytes:    cf.offset,
+		Duration: time.Since(start),
+		Err:      err,
+	})
+	return lineno, err
+}
+
+// result returns the line count and error of a parse that returned err.
+// A read error or limit takes precedence, since it cut the input short.
+func (cf *parser) result(err error) (uint, error) {
+	if cf.err != nil {
+		return cf.lineno(), cf.err
+	}
+	return cf.lineno(), err
+}
+
+// lineno returns the number of the line the parser is on. Once the input
+// is consumed, this is the number of lines, where a final newline does not
+// start another line and empty input counts as one line.
+func (cf *parser) lineno() uint {
+	return cf.line
+}
+
+// fail turns err into a ParseError at the current position. In lenient
+// mode the error is recorded, the rest of the line is skipped and fail
+// returns nil so that parsing continues.
+func (cf *parser) fail(err error) error {
+	return cf.failAt(cf.line, cf.col, err)
+}
+
+// failAt is like fail, but reports the error at the given position.
+func (cf *parser) failAt(line, col uint, err error) error {
+	perr := ParseError{Line: line, Column: col, Err: err}
+	if d, ok := err.(*detailError); ok {
+		perr.Err, perr.Detail = d.err, d.detail
+	}
+	if !cf.lenient {
+		return &perr
+	}
+	cf.errs = append(cf.errs, perr)
+	for !cf.eol && !cf.eof {
+		cf.nextRune()
+	}
+	return nil
+}
+
+func (cf *parser) parse(set setter) error {
 	for {
+		ev, key, value, err := cf.step()
+		switch {
+		case err != nil:
+			return err
+		case ev == eventEOF:
+			return nil
+		case ev == eventKey:
+			if err := set(cf.section, key, value); err != nil {
+				cf.err = err
+				return err
+			}
+		}
+	}
+}
+
+// event is the kind of item returned by step.
+type event int
+
+const (
+	eventEOF event = iota
+	eventSection
+	eventKey
+	eventComment
+)
+
+// step reads the input up to the end of the next section header, variable
+// or, if keepComment is set, comment line, and returns it: the section
+// name, the variable name and its value, or the comment text. The input
+// is read the same way for Parse and Scanner, so that both apply the same
+// options. Comment lines are skipped unless keepComment is set, as are
+// sections and variables excluded by WithSections. In lenient mode syntax
+// errors are recorded and skipped; otherwise they are returned.
+func (cf *parser) step() (ev event, text, value string, err error) {
+	for {
+		offset := cf.offset
 		c := cf.nextRune()
 		if c == '\n' {
 			if cf.eof {
-				return cfg, nil
+				return eventEOF, "", "", nil
 			}
-			comment = false
 			continue
 		}
-		if comment || isspace(c) {
+		if isspace(c) {
 			continue
 		}
-		if c == '#' || c == ';' {
-			comment = true
+		if cf.isComment(c) || c == '/' && cf.opts.slashComments {
+			line := cf.line
+			cf.comment = cf.comment[:0]
+			if c == '/' {
+				col := cf.col
+				if c = cf.nextRune(); c != '/' {
+					if err := cf.failAt(line, col, ErrInvalidKeyChar); err != nil {
+						return eventEOF, "", "", err
+					}
+					continue
+				}
+				cf.comment = append(cf.comment, '/')
+			}
+			for ; c != '\n'; c = cf.nextRune() {
+				if cf.keepComment {
+					cf.comment = utf8.AppendRune(cf.comment, c)
+				}
+			}
+			if cf.keepComment {
+				cf.keyLine, cf.keyOffset = line, offset
+				return eventComment, string(cf.comment), "", nil
+			}
 			continue
 		}
 		if c == '[' {
-			name, err = cf.getSectionKey()
+			line, col := cf.line, cf.col
+			section, err := cf.getSectionKey()
 			if err != nil {
-				return cfg, err
+				cf.section, cf.skip = "", true
+				if err := cf.fail(err); err != nil {
+					return eventEOF, "", "", err
+				}
+				continue
+			}
+			if cf.seen != nil {
+				if cf.seen[section] {
+					if err := cf.failAt(line, col, ErrDuplicateSection); err != nil {
+						return eventEOF, "", "", err
+					}
+				}
+				cf.seen[section] = true
+			}
+			cf.section, cf.skip = section+".", false
+			if cf.opts.sections != nil {
+				base, _ := splitSection(section)
+				cf.filtered = !cf.opts.sections[strings.ToLower(base)]
+			}
+			if cf.onSection != nil {
+				cf.onSection(section, offset)
+			}
+			if cf.filtered {
+				continue
+			}
+			cf.keyLine, cf.keyOffset = line, offset
+			return eventSection, section, "", nil
+		}
+		if c == '=' {
+			if err := cf.fail(ErrEmptyKey); err != nil {
+				return eventEOF, "", "", err
 			}
-			name += "."
 			continue
 		}
 		if !isalpha(c) {
-			return cfg, ErrInvalidKeyChar
+			if err := cf.fail(ErrInvalidKeyChar); err != nil {
+				return eventEOF, "", "", err
+			}
+			continue
+		}
+		if cf.section == "" && !cf.skip {
+			if err := cf.fail(ErrKeyOutsideSection); err != nil {
+				return eventEOF, "", "", err
+			}
+			continue
+		}
+		if cf.filtered {
+			cf.skipValue()
+			continue
 		}
-		key := name + string(c)
+		cf.keyLine, cf.keyOffset = cf.line, offset
+		key := string(cf.fold(c))
 		value, err := cf.getValue(&key)
 		if err != nil {
-			return cfg, err
+			if err := cf.fail(err); err != nil {
+				return eventEOF, "", "", err
+			}
+			continue
 		}
-		cfg[key] = value
+		if cf.skip {
+			continue
+		}
+		if cf.err != nil {
+			// The value was cut short by a read error or limit.
+			return eventEOF, "", "", cf.err
+		}
+		return eventKey, key, value, nil
 	}
 }
 
 func (cf *parser) nextRune() rune {
-	if len(cf.runes) == 0 {
-		cf.eof = true
+	if cf.eof {
 		return '\n'
 	}
-	c := cf.runes[0]
+	c, size, err := cf.readRune()
+	if err != nil {
+		// The end of input is reported just past the last rune, or at the
+		// final newline, which does not start another line.
+		if !cf.eol {
+			cf.col++
+		}
+		cf.setEOF(err)
+		return '\n'
+	}
+	// The position is advanced lazily, so that a newline is reported at
+	// the end of the line it terminates.
+	if cf.eol {
+		cf.line++
+		cf.col = 0
+		cf.eol = false
+	}
+	cf.col++
+	if c == utf8.RuneError && size == 1 && cf.opts.rejectInvalidUTF8 {
+		cf.setEOF(&ParseError{
+			Line:   cf.line,
+			Column: cf.col,
+			Err:    ErrInvalidUTF8,
+			Detail: fmt.Sprintf("at byte offset %d", cf.offset),
+		})
+		return '\n'
+	}
+	cf.offset += int64(size)
 	if c == '\r' {
-		/* DOS like systems */
-		if len(cf.runes) > 1 && cf.runes[1] == '\n' {
-			cf.runes = cf.runes[1:]
-			c = '\n'
+		/* DOS (CRLF) and classic Mac (CR) line endings */
+		size, err := cf.skipLF()
+		if err != nil {
+			cf.setEOF(err)
 		}
+		cf.offset += int64(size)
+		c = '\n'
 	}
 	if c == '\n' {
-		cf.linenr++
+		cf.eol = true
 	}
-	if len(cf.runes) == 0 {
-		cf.eof = true
-		cf.linenr++
-		c = '\n'
+	if cf.overLimit() {
+		cf.setEOF(&ParseError{Line: cf.line, Column: cf.col, Err: ErrLimitExceeded})
+		return '\n'
+	}
+	if cf.ctx != nil {
+		cf.ticks++
+		if cf.ticks%ctxCheckInterval == 0 {
+			if err := cf.ctx.Err(); err != nil {
+				cf.setEOF(err)
+				return '\n'
+			}
+		}
 	}
-	cf.runes = cf.runes[1:]
 	return c
 }
 
+// readRune returns the next rune of the input and its size in bytes.
+func (cf *parser) readRune() (rune, int, error) {
+	if cf.src != nil {
+		return cf.src.ReadRune()
+	}
+	if len(cf.buf) == 0 {
+		return 0, 0, io.EOF
+	}
+	c, size := utf8.DecodeRune(cf.buf)
+	cf.buf = cf.buf[size:]
+	return c, size, nil
+}
+
+// skipLF consumes the next rune if it is a '\n' and returns its size.
+func (cf *parser) skipLF() (int, error) {
+	if cf.src == nil {
+		if len(cf.buf) > 0 && cf.buf[0] == '\n' {
+			cf.buf = cf.buf[1:]
+			return 1, nil
+		}
+		return 0, nil
+	}
+	c, size, err := cf.src.ReadRune()
+	if err == io.EOF {
+		// Nothing follows the '\r'; the next readRune reports the end.
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if c != '\n' {
+		return 0, cf.src.UnreadRune()
+	}
+	return size, nil
+}
+
+func (cf *parser) overLimit() bool {
+	return cf.opts.maxBytes > 0 && cf.offset > cf.opts.maxBytes ||
+		cf.opts.maxLines > 0 && cf.line > cf.opts.maxLines
+}
+
+// setEOF marks the end of input. Any error other than io.EOF is kept so
+// that it can be reported instead of the parse result.
+func (cf *parser) setEOF(err error) {
+	cf.eof = true
+	if err != io.EOF && cf.err == nil {
+		cf.err = err
+	}
+}
+
 func (cf *parser) getSectionKey() (string, error) {
-	name := ""
+	name := cf.name[:0]
+	defer func() { cf.name = name[:0] }()
 	for {
 		c := cf.nextRune()
 		if cf.eof {
-			return "", ErrUnexpectedEOF
+			return "", headerEOF("[" + string(name))
 		}
 		if c == ']' {
-			return name, nil
+			if len(name) == 0 {
+				return "", ErrInvalidSectionChar
+			}
+			return string(name), nil
 		}
 		if isspace(c) {
-			return cf.getExtendedSectionKey(name, c)
+			var err error
+			if name, err = cf.getExtendedSectionKey(name, c); err != nil {
+				return "", err
+			}
+			return string(name), nil
+		}
+		if c == '.' && cf.opts.rejectLegacy {
+			return "", ErrLegacySectionSyntax
 		}
 		if !iskeychar(c) && c != '.' {
 			return "", ErrInvalidSectionChar
 		}
-		name += string(lower(c))
+		name = utf8.AppendRune(name, cf.fold(c))
 	}
 }
 
 // config: [BaseSection "ExtendedSection"]
-func (cf *parser) getExtendedSectionKey(name string, c rune) (string, error) {
+func (cf *parser) getExtendedSectionKey(name []byte, c rune) ([]byte, error) {
 	for {
 		if c == '\n' {
-			cf.linenr--
-			return "", ErrSectionNewLine
+			if cf.eof {
+				return nil, headerEOF("[" + string(name))
+			}
+			return nil, ErrSectionNewLine
 		}
 		c = cf.nextRune()
 		if !isspace(c) {
@@ -117,14 +692,21 @@ func (cf *parser) getExtendedSectionKey(name string, c rune) (string, error) {
 		}
 	}
 	if c != '"' {
-		return "", ErrMissingStartQuote
+		return nil, ErrMissingStartQuote
+	}
+	base := len(name)
+	name = append(name, '.')
+	// partial returns the header read so far, for errors at EOF.
+	partial := func() string {
+		return "[" + string(name[:base]) + ` "` + string(name[base+1:])
 	}
-	name += "."
 	for {
 		c = cf.nextRune()
 		if c == '\n' {
-			cf.linenr--
-			return "", ErrSectionNewLine
+			if cf.eof {
+				return nil, headerEOF(partial())
+			}
+			return nil, ErrSectionNewLine
 		}
 		if c == '"' {
 			break
@@ -132,24 +714,93 @@ func (cf *parser) getExtendedSectionKey(name string, c rune) (string, error) {
 		if c == '\\' {
 			c = cf.nextRune()
 			if c == '\n' {
-				cf.linenr--
-				return "", ErrSectionNewLine
+				if cf.eof {
+					return nil, headerEOF(partial() + `\`)
+				}
+				return nil, ErrSectionNewLine
 			}
 		}
-		name += string(c)
+		name = utf8.AppendRune(name, c)
 	}
-	if cf.nextRune() != ']' {
-		return "", ErrMissingClosingBracket
+	if c = cf.nextRune(); c != ']' {
+		if cf.eof {
+			return nil, headerEOF(partial() + `"`)
+		}
+		return nil, ErrMissingClosingBracket
 	}
 	return name, nil
 }
 
+// headerEOF returns the error for a section header cut short by the end of
+// the input, naming the part that was read as in
+// "unexpected EOF after [core".
+func headerEOF(header string) error {
+	return &detailError{err: ErrUnexpectedEOF, detail: "after " + header}
+}
+
+// detailError carries a sentinel error and the Detail of the ParseError
+// that fail makes of it.
+type detailError struct {
+	err    error
+	detail string
+}
+
+func (e *detailError) Error() string {
+	return e.err.Error() + " " + e.detail
+}
+
+func (e *detailError) Unwrap() error {
+	return e.err
+}
+
+// isComment reports whether c starts a comment, which '#' and ';' do
+// unless disabled by DisableHashComments or DisableSemicolonComments.
+func (cf *parser) isComment(c rune) bool {
+	return c == '#' && !cf.opts.noHashComments || c == ';' && !cf.opts.noSemiComments
+}
+
+// skipValue consumes the rest of a variable that is not stored because of
+// WithSections. It follows quotes, comments and continuation lines like
+// parseValue, but does not validate the name or escape sequences.
+func (cf *parser) skipValue() {
+	quote := false
+	unread := rune(-1)
+	for {
+		c := unread
+		if c < 0 {
+			c = cf.nextRune()
+		}
+		unread = -1
+		comments := !quote && !cf.opts.noInlineComments
+		if comments && c == '/' && cf.opts.slashComments {
+			if unread = cf.nextRune(); unread == '/' {
+				for cf.nextRune() != '\n' {
+				}
+				return
+			}
+		}
+		switch {
+		case c == '\n':
+			return
+		case c == '\\':
+			cf.nextRune()
+		case c == '"':
+			quote = !quote
+		case comments && cf.isComment(c):
+			for cf.nextRune() != '\n' {
+			}
+			return
+		}
+	}
+}
+
 func (cf *parser) getValue(name *string) (string, error) {
 	var c rune
-	var err error
-	var value string
 
+	cf.quoted, cf.bare = false, false
+	cf.comment = cf.comment[:0]
 	/* Get the full name */
+	buf := append(cf.name[:0], *name...)
 	for {
 		c = cf.nextRune()
 		if cf.eof {
@@ -158,20 +809,38 @@ func (cf *parser) getValue(name *string) (string, error) {
 		if !iskeychar(c) {
 			break
 		}
-		*name += string(lower(c))
+		buf = utf8.AppendRune(buf, cf.fold(c))
 	}
+	*name, cf.name = string(buf), buf[:0]
 
+	var before []rune
 	for c == ' ' || c == '\t' {
+		if cf.opts.checkSpacing != nil {
+			before = append(before, c)
+		}
 		c = cf.nextRune()
 	}
 
-	if c != '\n' {
-		if c != '=' {
-			return "", ErrInvalidKeyChar
+	if c == '\n' {
+		cf.bare = true
+		if cf.opts.bareTrue {
+			return "true", nil
 		}
-		value, err = cf.parseValue()
-		if err != nil {
-			return "", err
+		return "", nil
+	}
+	if c != '=' {
+		return "", ErrInvalidKeyChar
+	}
+	line, col := cf.line, cf.col
+	value, err := cf.parseValue()
+	if err == nil && cf.opts.checkSpacing != nil {
+		got, want := string(before)+"="+string(cf.lead), " = "
+		if cf.leadEOL {
+			// An empty value needs no trailing space.
+			want = " ="
+		}
+		if got != want {
+			cf.opts.checkSpacing(SpacingWarning{Line: line, Column: col, Got: got, Want: want})
 		}
 	}
 	return value, err
@@ -180,38 +849,82 @@ func (cf *parser) getValue(name *string) (string, error) {
 func (cf *parser) parseValue() (string, error) {
 	var quote, comment bool
 	var space int
-
-	var value string
+	// blank holds the whitespace counted in space if values are raw.
+	var blank []byte
+	raw := cf.opts.rawValues
 
 	// strbuf_reset(&cf->value);
+	cf.value = cf.value[:0]
+	cf.lead = cf.lead[:0]
+	leading := cf.opts.checkSpacing != nil
+	// unread is a rune read ahead after a single '/', or -1.
+	unread := rune(-1)
 	for {
-		c := cf.nextRune()
+		c := unread
+		if c < 0 {
+			c = cf.nextRune()
+		}
+		unread = -1
+		if leading {
+			if isspace(c) && c != '\n' {
+				cf.lead = utf8.AppendRune(cf.lead, c)
+				continue
+			}
+			leading, cf.leadEOL = false, c == '\n'
+		}
 		if c == '\n' {
 			if quote {
-				cf.linenr--
 				return "", ErrUnfinishedQuote
 			}
-			return value, nil
+			return string(cf.value), nil
 		}
 		if comment {
+			if cf.keepComment {
+				cf.comment = utf8.AppendRune(cf.comment, c)
+			}
 			continue
 		}
 		if isspace(c) && !quote {
-			if len(value) > 0 {
+			if len(cf.value) > 0 {
 				space++
+				if raw {
+					blank = utf8.AppendRune(blank, c)
+				}
 			}
 			continue
 		}
-		if !quote {
-			if c == ';' || c == '#' {
+		if !quote && !cf.opts.noInlineComments {
+			if cf.isComment(c) {
 				comment = true
+				if cf.keepComment {
+					cf.comment = utf8.AppendRune(cf.comment, c)
+				}
 				continue
 			}
+			if c == '/' && cf.opts.slashComments {
+				if unread = cf.nextRune(); unread == '/' {
+					comment, unread = true, -1
+					if cf.keepComment {
+						cf.comment = append(cf.comment, "//"...)
+					}
+					continue
+				}
+			}
+		}
+		if raw {
+			cf.value, blank, space = append(cf.value, blank...), blank[:0], 0
 		}
 		for space != 0 {
-			value += " "
+			cf.value = append(cf.value, ' ')
 			space--
 		}
+		if c == '\\' && raw {
+			cf.value = append(cf.value, '\\')
+			if c = cf.nextRune(); !cf.eof {
+				cf.value = utf8.AppendRune(cf.value, c)
+			}
+			continue
+		}
 		if c == '\\' {
 			c = cf.nextRune()
 			switch c {
@@ -223,18 +936,63 @@ func (cf *parser) parseValue() (string, error) {
 				c = '\b'
 			case 'n':
 				c = '\n'
+			case '\\', '"':
+				/* taken literally */
+			case 'x':
+				if cf.opts.hexEscapes {
+					var ok bool
+					if c, ok = cf.hexEscape(); !ok {
+						return "", ErrInvalidEscapeSequence
+					}
+					break
+				}
+				fallthrough
 			default:
-				return "", ErrInvalidEscapeSequence
+				if !cf.opts.lenientEscapes {
+					return "", ErrInvalidEscapeSequence
+				}
+				cf.value = append(cf.value, '\\')
 			}
-			value += string(c)
+			cf.value = utf8.AppendRune(cf.value, c)
 			continue
 		}
 		if c == '"' {
 			quote = !quote
-			continue
+			cf.quoted = true
+			if !raw {
+				continue
+			}
+		}
+		cf.value = utf8.AppendRune(cf.value, c)
+	}
+}
+
+// hexEscape reads the two hexadecimal digits of a \xNN escape.
+func (cf *parser) hexEscape() (rune, bool) {
+	var n rune
+	for i := 0; i < 2; i++ {
+		c := cf.nextRune()
+		switch {
+		case c >= '0' && c <= '9':
+			n = n<<4 | (c - '0')
+		case c >= 'a' && c <= 'f':
+			n = n<<4 | (c - 'a' + 10)
+		case c >= 'A' && c <= 'F':
+			n = n<<4 | (c - 'A' + 10)
+		default:
+			return 0, false
 		}
-		value += string(c)
 	}
+	return n, true
+}
+
+// fold lowercases c, a rune of a section or variable name, unless the
+// KeepCase option is set.
+func (cf *parser) fold(c rune) rune {
+	if cf.opts.keepCase {
+		return c
+	}
+	return lower(c)
 }
 
 func lower(c rune) rune {
@@ -1,10 +1,19 @@
 package goconfig
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -17,7 +26,7 @@ func TestDanyel(t *testing.T) {
 	}
 	config, lineno, err := Parse(bytes)
 	assert.Equal(t, nil, err)
-	assert.Equal(t, 10, int(lineno))
+	assert.Equal(t, 9, int(lineno))
 	assert.Equal(t, "Danyel Bayraktar", config["user.name"])
 	assert.Equal(t, "cydrop@gmail.com", config["user.email"])
 	assert.Equal(t, "subl -w", config["core.editor"])
@@ -27,11 +36,78 @@ func TestDanyel(t *testing.T) {
 func TestInvalidKey(t *testing.T) {
 	invalidConfig := ".name = Danyel"
 	config, lineno, err := Parse([]byte(invalidConfig))
-	assert.Equal(t, ErrInvalidKeyChar, err)
+	assert.ErrorIs(t, err, ErrInvalidKeyChar)
 	assert.Equal(t, 1, int(lineno))
 	assert.Equal(t, map[string]string{}, config)
 }
 
+func TestParseError(t *testing.T) {
+	invalidConfig := "[user]\n\tname = Danyel\n[us@er]\n"
+	_, _, err := Parse([]byte(invalidConfig))
+	var perr *ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, 3, int(perr.Line))
+		assert.Equal(t, 4, int(perr.Column))
+		assert.Equal(t, ErrInvalidSectionChar, perr.Err)
+	}
+	assert.ErrorIs(t, err, ErrInvalidSectionChar)
+	assert.Equal(t, "line 3, column 4: invalid character in section", err.Error())
+}
+
+func TestParseErrorColumn(t *testing.T) {
+	tests := []struct {
+		input  string
+		line   int
+		column int
+		err    error
+	}{
+		{"[user]\n\t.name = Danyel", 2, 2, ErrInvalidKeyChar},
+		{"[user]\n\tna@me = Danyel", 2, 4, ErrInvalidKeyChar},
+		{"[user]\r\n\tna@me = Danyel", 2, 4, ErrInvalidKeyChar},
+		{"[remote origin]", 1, 9, ErrMissingStartQuote},
+		{"[user]\n\tname = Dan\\qyel", 2, 13, ErrInvalidEscapeSequence},
+		{"[user]\r\n\tname = Dan\\qyel", 2, 13, ErrInvalidEscapeSequence},
+		{"[remote \"origin\n\"]", 1, 16, ErrSectionNewLine},
+		{"[]\n\tname = Danyel", 1, 2, ErrInvalidSectionChar},
+		{"foo = bar\n[core]\n", 1, 1, ErrKeyOutsideSection},
+		{"[core]\n=\n", 2, 1, ErrEmptyKey},
+		{"[core]\n  = x\n", 2, 3, ErrEmptyKey},
+		{"[core]\r\n\tname = x\r\n\t=x\r\n", 3, 2, ErrEmptyKey},
+		{"= x\n", 1, 1, ErrEmptyKey},
+	}
+	for _, test := range tests {
+		_, _, err := Parse([]byte(test.input))
+		var perr *ParseError
+		if assert.True(t, errors.As(err, &perr), test.input) {
+			assert.Equal(t, test.line, int(perr.Line), test.input)
+			assert.Equal(t, test.column, int(perr.Column), test.input)
+			assert.Equal(t, test.err, perr.Err, test.input)
+		}
+	}
+}
+
+func TestKeyOutsideSection(t *testing.T) {
+	config, lineno, err := Parse([]byte("# comment\n  foo = bar\n[core]\n\tbare\n"))
+	assert.ErrorIs(t, err, ErrKeyOutsideSection)
+	assert.EqualError(t, err, "line 2, column 3: key does not contain a section")
+	assert.Equal(t, 2, int(lineno))
+	assert.Equal(t, map[string]string{}, config)
+
+	config, _, errs := ParseLenient([]byte("foo = bar\n[core]\n\tbare\n"))
+	assert.Equal(t, []ParseError{{Line: 1, Column: 1, Err: ErrKeyOutsideSection}}, errs)
+	assert.Equal(t, map[string]string{"core.bare": ""}, config)
+
+	// Keys below an invalid header are not outside a section.
+	_, _, errs = ParseLenient([]byte("[co@re]\n\tbare\n"))
+	assert.Equal(t, []ParseError{{Line: 1, Column: 4, Err: ErrInvalidSectionChar}}, errs)
+
+	s := NewScanner([]byte("foo = bar\n"))
+	typ, _, line := s.Scan()
+	assert.Equal(t, TokenError, typ)
+	assert.Equal(t, uint(1), line)
+	assert.ErrorIs(t, s.Err(), ErrKeyOutsideSection)
+}
+
 func TestNoNewLine(t *testing.T) {
 	validConfig := "[user] name = Danyel"
 	config, lineno, err := Parse([]byte(validConfig))
@@ -40,6 +116,44 @@ func TestNoNewLine(t *testing.T) {
 	assert.Equal(t, map[string]string{"user.name": "Danyel"}, config)
 }
 
+func TestSectionTrailingText(t *testing.T) {
+	// git reads the rest of a header line as a variable, so only text that
+	// is not a valid variable is an error.
+	valid := map[string]map[string]string{
+		"[core] garbage\n":         {"core.garbage": ""},
+		"[core]bare":               {"core.bare": ""},
+		"[core] ; ok\n\tbare\n":    {"core.bare": ""},
+		"[core]\t# ok":             {},
+		"[a \"b\"]junk = 1 ; ok\n": {"a.b.junk": "1"},
+	}
+	for input, expected := range valid {
+		config, _, err := Parse([]byte(input))
+		assert.Equal(t, nil, err, input)
+		assert.Equal(t, expected, config, input)
+	}
+
+	tests := []struct {
+		input  string
+		line   int
+		column int
+		err    error
+	}{
+		{"[core] @junk\n", 1, 8, ErrInvalidKeyChar},
+		{"[core] x y\n", 1, 10, ErrInvalidKeyChar},
+		{"[core] = x\n", 1, 8, ErrEmptyKey},
+		{"[user]\n\tname = x\n[core] ]\n", 3, 8, ErrInvalidKeyChar},
+	}
+	for _, test := range tests {
+		_, _, err := Parse([]byte(test.input))
+		var perr *ParseError
+		if assert.True(t, errors.As(err, &perr), test.input) {
+			assert.Equal(t, test.line, int(perr.Line), test.input)
+			assert.Equal(t, test.column, int(perr.Column), test.input)
+			assert.Equal(t, test.err, perr.Err, test.input)
+		}
+	}
+}
+
 func TestExtended(t *testing.T) {
 	validConfig := `[http "https://my-website.com"] sslVerify = false`
 	config, lineno, err := Parse([]byte(validConfig))
@@ -48,6 +162,656 @@ func TestExtended(t *testing.T) {
 	assert.Equal(t, map[string]string{`http.https://my-website.com.sslverify`: "false"}, config)
 }
 
+func TestParseReader(t *testing.T) {
+	filename := "configs/danyel.gitconfig"
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Reading file %v failed", filename)
+	}
+	expected, expectedLineno, expectedErr := Parse(data)
+	config, lineno, err := ParseReader(bytes.NewReader(data))
+	assert.Equal(t, expectedErr, err)
+	assert.Equal(t, expectedLineno, lineno)
+	assert.Equal(t, expected, config)
+}
+
+func TestParseReaderSameErrors(t *testing.T) {
+	for _, input := range []string{".name = Danyel", "[user", "[user]\r\nname = \"Danyel"} {
+		_, expectedLineno, expectedErr := Parse([]byte(input))
+		_, lineno, err := ParseReader(bytes.NewBufferString(input))
+		assert.Equal(t, expectedErr, err, input)
+		assert.Equal(t, expectedLineno, lineno, input)
+	}
+}
+
+type failingReader struct{}
+
+var errRead = errors.New("read failed")
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errRead
+}
+
+func TestParseReaderError(t *testing.T) {
+	_, _, err := ParseReader(failingReader{})
+	assert.Equal(t, errRead, err)
+}
+
+func TestParseFile(t *testing.T) {
+	filename := "configs/danyel.gitconfig"
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Reading file %v failed", filename)
+	}
+	expected, _, _ := Parse(data)
+	config, lineno, err := ParseFile(filename)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 9, int(lineno))
+	assert.Equal(t, expected, config)
+}
+
+func TestParseFileMissing(t *testing.T) {
+	config, _, err := ParseFile("configs/does-not-exist.gitconfig")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.Nil(t, config)
+}
+
+func TestParseFileEmpty(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "empty.gitconfig")
+	if err := ioutil.WriteFile(filename, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config, lineno, err := ParseFile(filename)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, int(lineno))
+	assert.Equal(t, map[string]string{}, config)
+}
+
+func TestParseFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults/gitconfig": {Data: []byte("[core]\n\teditor = vi\n")},
+		"broken/gitconfig":   {Data: []byte("[core\n")},
+	}
+	config, lineno, err := ParseFS(fsys, "defaults/gitconfig")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, int(lineno))
+	assert.Equal(t, map[string]string{"core.editor": "vi"}, config)
+
+	config, _, err = ParseFS(fsys, "missing/gitconfig")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	assert.Nil(t, config)
+	var perr *ParseError
+	assert.False(t, errors.As(err, &perr))
+
+	_, lineno, err = ParseFS(fsys, "broken/gitconfig")
+	assert.True(t, errors.As(err, &perr))
+	assert.True(t, errors.Is(err, ErrSectionNewLine))
+	assert.Equal(t, 1, int(lineno))
+}
+
+func TestLineEndings(t *testing.T) {
+	expected := map[string]string{"user.name": "Danyel", "user.email": "cydrop@gmail.com", "core.editor": "vim"}
+	tests := []string{
+		"[user]\n\tname = Danyel\n\temail = cydrop@gmail.com\n[core]\n\teditor = vim\n",
+		"[user]\r\n\tname = Danyel\r\n\temail = cydrop@gmail.com\r\n[core]\r\n\teditor = vim\r\n",
+		"[user]\r\tname = Danyel\r\temail = cydrop@gmail.com\r[core]\r\teditor = vim\r",
+		"[user]\r\n\tname = Danyel\r\temail = cydrop@gmail.com\n[core]\r\teditor = vim\r\n",
+	}
+	for _, input := range tests {
+		config, lineno, err := Parse([]byte(input))
+		assert.Equal(t, nil, err, input)
+		assert.Equal(t, 5, int(lineno), input)
+		assert.Equal(t, expected, config, input)
+	}
+
+	config, lineno, err := Parse([]byte("[user]\r\r\tname = Danyel\r"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, int(lineno))
+	assert.Equal(t, map[string]string{"user.name": "Danyel"}, config)
+
+	_, _, err = Parse([]byte("[user]\r\tname = \"Dan\ryel\""))
+	var perr *ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, ErrUnfinishedQuote, perr.Err)
+		assert.Equal(t, 2, int(perr.Line))
+	}
+}
+
+func TestLineCount(t *testing.T) {
+	for _, test := range []struct {
+		input  string
+		lineno int
+	}{
+		{"", 1},
+		{"\n", 1},
+		{"[a]", 1},
+		{"[a]\n", 1},
+		{"[a]\n\tb = c", 2},
+		{"[a]\n\tb = c\n", 2},
+		{"[a]\n\tb = c\n\n", 3},
+		{"[a]\n\tb = c\n\n\n", 4},
+		{"[a]\n\tb = c\n  ", 3},
+		{"[a]\r\n\tb = c\r\n", 2},
+		{"[a]\n\tb = c \\\n", 2},
+		{"# comment", 1},
+		{"# comment\n", 1},
+	} {
+		_, lineno, err := Parse([]byte(test.input))
+		assert.Equal(t, nil, err, test.input)
+		assert.Equal(t, test.lineno, int(lineno), test.input)
+		_, lineno, err = ParseReader(strings.NewReader(test.input))
+		assert.Equal(t, nil, err, test.input)
+		assert.Equal(t, test.lineno, int(lineno), test.input)
+	}
+
+	for _, test := range []struct {
+		input        string
+		line, column int
+		err          error
+	}{
+		{"[a", 1, 3, ErrUnexpectedEOF},
+		{"[a\n", 1, 3, ErrSectionNewLine},
+		{"[a]\n\tb = \"c", 2, 8, ErrUnfinishedQuote},
+		{"[a]\n\tb = \"c\n", 2, 8, ErrUnfinishedQuote},
+		{"[a]\n\tb = \"c\\\n", 2, 9, ErrUnfinishedQuote},
+		{"[a]\n\n\tb = \\q\n", 3, 7, ErrInvalidEscapeSequence},
+	} {
+		_, lineno, err := Parse([]byte(test.input))
+		var perr *ParseError
+		if assert.True(t, errors.As(err, &perr), test.input) {
+			assert.ErrorIs(t, perr.Err, test.err, test.input)
+			assert.Equal(t, test.line, int(perr.Line), test.input)
+			assert.Equal(t, test.column, int(perr.Column), test.input)
+			assert.Equal(t, test.line, int(lineno), test.input)
+		}
+	}
+}
+
+func TestUnterminatedSection(t *testing.T) {
+	tests := []struct {
+		input  string
+		line   int
+		column int
+		msg    string
+	}{
+		{"[", 1, 2, "unexpected EOF after ["},
+		{"[core", 1, 6, "unexpected EOF after [core"},
+		{"[Core ", 1, 7, "unexpected EOF after [core"},
+		{"[core \"sub", 1, 11, `unexpected EOF after [core "sub`},
+		{"[core \"S\\\"b\\", 1, 13, `unexpected EOF after [core "S"b\`},
+		{"[core \"sub\"", 1, 12, `unexpected EOF after [core "sub"`},
+		{"[user]\n\tname = x\n[co.re", 3, 7, "unexpected EOF after [co.re"},
+		{"[user]\r\n[", 2, 2, "unexpected EOF after ["},
+	}
+	for _, test := range tests {
+		for _, parse := range []func(string) (uint, error){
+			func(s string) (uint, error) { _, lineno, err := Parse([]byte(s)); return lineno, err },
+			func(s string) (uint, error) { _, lineno, err := ParseReader(strings.NewReader(s)); return lineno, err },
+		} {
+			lineno, err := parse(test.input)
+			var perr *ParseError
+			if assert.True(t, errors.As(err, &perr), test.input) {
+				assert.Equal(t, ErrUnexpectedEOF, perr.Err, test.input)
+				assert.Equal(t, test.msg, perr.Err.Error()+" "+perr.Detail, test.input)
+				assert.True(t, strings.HasSuffix(err.Error(), ": "+test.msg), test.input)
+				assert.Equal(t, test.line, int(perr.Line), test.input)
+				assert.Equal(t, test.column, int(perr.Column), test.input)
+				assert.Equal(t, test.line, int(lineno), test.input)
+			}
+		}
+	}
+
+	// A '\r' that ends the input ends the header line on both paths.
+	for _, input := range []string{"[\r", "[a \r", "[a \"b\r", "[a \"b\\\r", "[a \"b\"\r", "[a]\r\tb = \"c\r"} {
+		expected, lineno, expectedErr := Parse([]byte(input))
+		config, line, err := ParseReader(strings.NewReader(input))
+		assert.Equal(t, expectedErr, err, input)
+		assert.Equal(t, lineno, line, input)
+		assert.Equal(t, expected, config, input)
+		assert.False(t, errors.Is(err, ErrUnexpectedEOF), input)
+	}
+
+	// Only the end of the input gives a Detail; a '\r' before it does not.
+	for _, test := range []struct {
+		input  string
+		column int
+	}{
+		{"[\r", 2},
+		{"[a \r", 4},
+		{"[a \"b\r", 6},
+	} {
+		for _, parse := range []func(string) (uint, error){
+			func(s string) (uint, error) { _, lineno, err := Parse([]byte(s)); return lineno, err },
+			func(s string) (uint, error) { _, lineno, err := ParseReader(strings.NewReader(s)); return lineno, err },
+		} {
+			_, err := parse(test.input)
+			var perr *ParseError
+			if assert.True(t, errors.As(err, &perr), test.input) {
+				assert.Equal(t, ErrSectionNewLine, perr.Err, test.input)
+				assert.Equal(t, "", perr.Detail, test.input)
+				assert.Equal(t, test.column, int(perr.Column), test.input)
+			}
+		}
+	}
+
+	// A newline, unlike the end of input, is a newline in the header.
+	_, _, err := Parse([]byte("[core \"sub\n"))
+	assert.ErrorIs(t, err, ErrSectionNewLine)
+	_, _, err = Parse([]byte("[core \"sub\"\n"))
+	assert.ErrorIs(t, err, ErrMissingClosingBracket)
+}
+
+func TestBOM(t *testing.T) {
+	plain := "[core]\n\tbare = true\n"
+	expected, expectedLineno, err := Parse([]byte(plain))
+	assert.Equal(t, nil, err)
+
+	config, lineno, err := Parse([]byte("\xef\xbb\xbf" + plain))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, expectedLineno, lineno)
+	assert.Equal(t, expected, config)
+
+	config, lineno, err = ParseReader(bytes.NewBufferString("\xef\xbb\xbf" + plain))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, expectedLineno, lineno)
+	assert.Equal(t, expected, config)
+
+	_, _, err = Parse([]byte("[core]\n\xef\xbb\xbf\tbare = true\n"))
+	assert.ErrorIs(t, err, ErrInvalidKeyChar)
+
+	for _, input := range []string{"\xef\xbb[core]", "\xef"} {
+		_, _, err = Parse([]byte(input))
+		assert.ErrorIs(t, err, ErrPartialBOM, input)
+		_, _, err = ParseReader(bytes.NewBufferString(input))
+		assert.ErrorIs(t, err, ErrPartialBOM, input)
+	}
+}
+
+func TestEscapedBackslashAndQuote(t *testing.T) {
+	validConfig := `[core]
+	path = C:\\Users\\Danyel
+	quoted = "C:\\Program Files\\Git"
+[user]
+	msg = say \"hi\"
+	mixed = "say \"hi\" to \\\\server"`
+	config, _, err := Parse([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `C:\Users\Danyel`, config["core.path"])
+	assert.Equal(t, `C:\Program Files\Git`, config["core.quoted"])
+	assert.Equal(t, `say "hi"`, config["user.msg"])
+	assert.Equal(t, `say "hi" to \\server`, config["user.mixed"])
+}
+
+func TestInlineComments(t *testing.T) {
+	validConfig := `[remote "origin"]
+	url = "http://x#y"
+	semi = "a;b"
+	after = "x" # comment
+	afterSemi = "x";comment
+	glued = "x"#comment
+	open = a "b # c" d # e
+	bare = x # comment
+	tight = x#y
+	spaced = "a b"   ; comment
+	empty = # comment
+	quotedHash = "#"`
+	config, _, err := Parse([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"remote.origin.url":        "http://x#y",
+		"remote.origin.semi":       "a;b",
+		"remote.origin.after":      "x",
+		"remote.origin.aftersemi":  "x",
+		"remote.origin.glued":      "x",
+		"remote.origin.open":       "a b # c d",
+		"remote.origin.bare":       "x",
+		"remote.origin.tight":      "x",
+		"remote.origin.empty":      "",
+		"remote.origin.quotedhash": "#",
+		"remote.origin.spaced":     "a b",
+	}, config)
+
+	// A backslash is not an escape for '#', so the line is invalid.
+	_, _, err = Parse([]byte("[core]\n\tx = a \\# b"))
+	assert.True(t, errors.Is(err, ErrInvalidEscapeSequence))
+}
+
+func TestContinuationLines(t *testing.T) {
+	validConfig := "[sendemail]\n" +
+		"\tsmtpServer = a\\\n" +
+		" b \\\n" +
+		"\tc\\\n" +
+		"d\n" +
+		"\tquoted = \"one \\\n" +
+		"  two\\\n" +
+		"\\tthree\"\n" +
+		"\tcrlf = one\\\r\n" +
+		"two\r\n" +
+		"\tcomment = one ; not continued \\\n" +
+		"\tlast = x \\\n" +
+		"# a comment, as the backslash ends the value\n" +
+		"\teof = end\\"
+	config, lineno, err := Parse([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 14, int(lineno))
+	assert.Equal(t, map[string]string{
+		// Leading whitespace of a continuation line is collapsed like any
+		// other whitespace outside quotes, and kept inside them.
+		"sendemail.smtpserver": "a b  cd",
+		"sendemail.quoted":     "one   two\tthree",
+		"sendemail.crlf":       "onetwo",
+		"sendemail.comment":    "one",
+		"sendemail.last":       "x ",
+		"sendemail.eof":        "end",
+	}, config)
+
+	entries, _, err := ParseEntries([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint(2), entries["sendemail.smtpserver"].Line)
+	assert.Equal(t, uint(6), entries["sendemail.quoted"].Line)
+	assert.Equal(t, uint(9), entries["sendemail.crlf"].Line)
+	assert.Equal(t, uint(11), entries["sendemail.comment"].Line)
+
+	tests := []struct {
+		input  string
+		line   int
+		column int
+		err    error
+	}{
+		{"[a]\n\tk = one\\\ntwo\\\nth\\qree\n", 4, 4, ErrInvalidEscapeSequence},
+		{"[a]\n\tk = \"one\\\ntwo\\\nthree\n", 4, 6, ErrUnfinishedQuote},
+		{"[a]\n\tk = one\\\ntwo\\\nthree\n\tna@me = x\n", 5, 4, ErrInvalidKeyChar},
+	}
+	for _, test := range tests {
+		_, lineno, err := Parse([]byte(test.input))
+		var perr *ParseError
+		if assert.True(t, errors.As(err, &perr), test.input) {
+			assert.Equal(t, test.line, int(perr.Line), test.input)
+			assert.Equal(t, test.column, int(perr.Column), test.input)
+			assert.Equal(t, test.err, perr.Err, test.input)
+			assert.Equal(t, test.line, int(lineno), test.input)
+		}
+		_, readerLineno, readerErr := ParseReader(strings.NewReader(test.input))
+		assert.Equal(t, err, readerErr, test.input)
+		assert.Equal(t, lineno, readerLineno, test.input)
+	}
+}
+
+func TestWhitespaceValues(t *testing.T) {
+	// Whitespace is only kept if it is quoted or followed by more of the
+	// value; the rules are those of git.
+	tests := map[string]string{
+		"k =    \n":              "",
+		"k =    ":                "",
+		"k = \t \t":              "",
+		"k =   ; comment":        "",
+		"k =   \\\n   \n":        "",
+		"k = \"  \"":             "  ",
+		"k = \"  \"   \n":        "  ",
+		"k =  \"\" \"  \"  ":     "  ",
+		"k = x   ":               "x",
+		"k = x \"\" ":            "x ",
+		"k = a  \"\"  b":         "a    b",
+		"k = \\t  ":              "\t",
+		"k = \"\" ":              "",
+		"k = \" \" x \" \" \t\n": "  x  ",
+	}
+	for line, expected := range tests {
+		input := "[a]\n" + line
+		config, _, err := Parse([]byte(input))
+		assert.Equal(t, nil, err, input)
+		assert.Equal(t, map[string]string{"a.k": expected}, config, input)
+		config, _, err = ParseReader(strings.NewReader(input))
+		assert.Equal(t, nil, err, input)
+		assert.Equal(t, map[string]string{"a.k": expected}, config, input)
+	}
+}
+
+func TestBareKeysAsTrue(t *testing.T) {
+	validConfig := "[core]\n\tbare\n\tempty =\n\tlast"
+	config, _, err := Parse([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{"core.bare": "", "core.empty": "", "core.last": ""}, config)
+
+	config, _, err = Parse([]byte(validConfig), BareKeysAsTrue())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{"core.bare": "true", "core.empty": "", "core.last": "true"}, config)
+}
+
+func TestHexEscapes(t *testing.T) {
+	input := "[core]\n\tbell = a\\x07b\n\tupper = \\x4A\\x4b\n\tlatin = caf\\xe9\n"
+	config, _, err := Parse([]byte(input), HexEscapes())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a\ab", config["core.bell"])
+	assert.Equal(t, "JK", config["core.upper"])
+	assert.Equal(t, "café", config["core.latin"])
+
+	for input, column := range map[string]int{
+		"[core]\n\tx = \\xg0": 8,
+		"[core]\n\tx = \\x0z": 9,
+		"[core]\n\tx = \\x0":  9,
+	} {
+		_, _, err := Parse([]byte(input), HexEscapes())
+		var perr *ParseError
+		if assert.True(t, errors.As(err, &perr), input) {
+			assert.Equal(t, ErrInvalidEscapeSequence, perr.Err, input)
+			assert.Equal(t, 2, int(perr.Line), input)
+			assert.Equal(t, column, int(perr.Column), input)
+		}
+	}
+
+	// Like git, the parser rejects \x by default.
+	var perr *ParseError
+	_, _, err = Parse([]byte(input))
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, ErrInvalidEscapeSequence, perr.Err)
+		assert.Equal(t, 2, int(perr.Line))
+		assert.Equal(t, 11, int(perr.Column))
+	}
+	config, _, err = Parse([]byte("[core]\n\tx = \\xe9\n"), LenientEscapes())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `\xe9`, config["core.x"])
+
+	// Raw control characters are kept.
+	config, _, err = Parse([]byte("[core]\n\tbell = a\ab\x1b\x00\x7f\n"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a\ab\x1b\x00\x7f", config["core.bell"])
+}
+
+func TestParseMulti(t *testing.T) {
+	validConfig := `[remote "origin"]
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	url = https://example.com/repo.git
+	fetch = +refs/tags/*:refs/tags/*
+[include]
+	path = one
+	path = two`
+	config, lineno, err := ParseMulti([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 7, int(lineno))
+	assert.Equal(t, map[string][]string{
+		"remote.origin.fetch": {"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"},
+		"remote.origin.url":   {"https://example.com/repo.git"},
+		"include.path":        {"one", "two"},
+	}, config)
+
+	single, _, err := Parse([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "+refs/tags/*:refs/tags/*", single["remote.origin.fetch"])
+	assert.Equal(t, "two", single["include.path"])
+}
+
+func TestParseString(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"[user]\n\tname = Danyel\n",
+		"\xef\xbb\xbf[core]\r\n\tbare\r\n",
+		"[user]\n\tname = \"unfinished\n",
+	} {
+		expected, expectedLine, expectedErr := Parse([]byte(input), BareKeysAsTrue())
+		config, lineno, err := ParseString(input, BareKeysAsTrue())
+		assert.Equal(t, expected, config, input)
+		assert.Equal(t, expectedLine, lineno, input)
+		assert.Equal(t, expectedErr, err, input)
+	}
+}
+
+func TestParseEntries(t *testing.T) {
+	validConfig := `[core]
+	plain = value
+	padded = "  value  "
+	mixed = a" "b
+	empty = ""
+	escaped = \"x\"
+	bare
+	multi = "first"
+	multi = second`
+	config, lineno, err := ParseEntries([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 9, int(lineno))
+	assert.Equal(t, map[string]Entry{
+		"core.plain":   {Value: "value", Line: 2, Offset: 8},
+		"core.padded":  {Value: "  value  ", Quoted: true, Line: 3, Offset: 23},
+		"core.mixed":   {Value: "a b", Quoted: true, Line: 4, Offset: 45},
+		"core.empty":   {Value: "", Quoted: true, Line: 5, Offset: 60},
+		"core.escaped": {Value: `"x"`, Line: 6, Offset: 72},
+		"core.bare":    {Value: "", Line: 7, Offset: 89},
+		"core.multi":   {Value: "second", Line: 9, Offset: 112},
+	}, config)
+
+	plain, _, err := Parse([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	for key, entry := range config {
+		assert.Equal(t, plain[key], entry.Value, key)
+	}
+}
+
+func TestParseEntriesOffsets(t *testing.T) {
+	input := "\xef\xbb\xbf[user]\r\n\tnäme = x\r\n  email = y # z\n[core] editor = vi"
+	config, _, err := ParseEntries([]byte(input))
+	assert.Equal(t, nil, err)
+	for key, name := range map[string]string{"user.näme": "näme", "user.email": "email", "core.editor": "editor"} {
+		entry := config[key]
+		assert.Equal(t, int64(strings.Index(input, name)), entry.Offset, key)
+		assert.Equal(t, name, input[entry.Offset:entry.Offset+int64(len(name))], key)
+	}
+	assert.Equal(t, uint(2), config["user.näme"].Line)
+	assert.Equal(t, uint(3), config["user.email"].Line)
+	assert.Equal(t, uint(4), config["core.editor"].Line)
+}
+
+func TestParseFunc(t *testing.T) {
+	validConfig := `[user]
+	name = Danyel
+[remote "Origin"]
+	URL = https://example.com/repo.git
+	fetch = a
+	fetch = b
+[Legacy.Sub]
+	key = value`
+	var entries [][4]string
+	lineno, err := ParseFunc([]byte(validConfig), func(section, subsection, key, value string) error {
+		entries = append(entries, [4]string{section, subsection, key, value})
+		return nil
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 8, int(lineno))
+	assert.Equal(t, [][4]string{
+		{"user", "", "name", "Danyel"},
+		{"remote", "Origin", "url", "https://example.com/repo.git"},
+		{"remote", "Origin", "fetch", "a"},
+		{"remote", "Origin", "fetch", "b"},
+		{"legacy", "sub", "key", "value"},
+	}, entries)
+}
+
+func TestParseFuncAbort(t *testing.T) {
+	errStop := errors.New("stop")
+	var keys []string
+	lineno, err := ParseFunc([]byte("[a]\n\tx = 1\n\ty = 2\n\tz = 3\n"), func(section, subsection, key, value string) error {
+		keys = append(keys, key)
+		if key == "y" {
+			return errStop
+		}
+		return nil
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, 3, int(lineno))
+	assert.Equal(t, []string{"x", "y"}, keys)
+}
+
+func TestParseLenient(t *testing.T) {
+	invalidConfig := `[user]
+	name = Danyel
+	na@me = typo
+	email = cydrop@gmail.com
+[co@re]
+	editor = vim
+[core]
+	pager = "less
+	bare
+	.dot = x
+[alias]
+	st = status`
+	config, lineno, errs := ParseLenient([]byte(invalidConfig))
+	assert.Equal(t, 12, int(lineno))
+	assert.Equal(t, map[string]string{
+		"user.name":  "Danyel",
+		"user.email": "cydrop@gmail.com",
+		"core.bare":  "",
+		"alias.st":   "status",
+	}, config)
+	assert.Equal(t, []ParseError{
+		{Line: 3, Column: 4, Err: ErrInvalidKeyChar},
+		{Line: 5, Column: 4, Err: ErrInvalidSectionChar},
+		{Line: 8, Column: 15, Err: ErrUnfinishedQuote},
+		{Line: 10, Column: 2, Err: ErrInvalidKeyChar},
+	}, errs)
+
+	strict, _, err := Parse([]byte(invalidConfig))
+	assert.ErrorIs(t, err, ErrInvalidKeyChar)
+	assert.Equal(t, map[string]string{"user.name": "Danyel"}, strict)
+
+	config, _, errs = ParseLenient([]byte("[core]\n\tbare = true\n"))
+	assert.Nil(t, errs)
+	assert.Equal(t, map[string]string{"core.bare": "true"}, config)
+}
+
+func TestValidate(t *testing.T) {
+	assert.Equal(t, nil, Validate([]byte("[user]\n\tname = Danyel\n")))
+	assert.Equal(t, nil, Validate(nil))
+
+	err := Validate([]byte("[user]\n\tname = Danyel\n[core\n"))
+	var perr *ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, 3, int(perr.Line))
+		assert.Equal(t, ErrSectionNewLine, perr.Err)
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	assert.Equal(t, nil, ValidateFile("configs/danyel.gitconfig"))
+	filename := filepath.Join(t.TempDir(), "invalid.gitconfig")
+	if err := ioutil.WriteFile(filename, []byte(".name = Danyel"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	assert.ErrorIs(t, ValidateFile(filename), ErrInvalidKeyChar)
+	assert.ErrorIs(t, ValidateFile("configs/does-not-exist.gitconfig"), os.ErrNotExist)
+}
+
+func BenchmarkValidate(b *testing.B) {
+	gitconfig := "configs/danyel.gitconfig"
+	bytes, err := ioutil.ReadFile(gitconfig)
+	if err != nil {
+		b.Fatalf("Couldn't read file %v: %s\n", gitconfig, err)
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_ = Validate(bytes)
+	}
+}
+
 func ExampleParse() {
 	gitconfig := "configs/danyel.gitconfig"
 	bytes, err := ioutil.ReadFile(gitconfig)
@@ -63,11 +827,102 @@ func ExampleParse() {
 	_, _ = fmt.Println(config["user.name"])
 	_, _ = fmt.Println(config["user.email"])
 	// Output:
-	// 10
+	// 9
 	// Danyel Bayraktar
 	// cydrop@gmail.com
 }
 
+func largeConfig(sections int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < sections; i++ {
+		fmt.Fprintf(&buf, "[remote \"r%d\"]\n\turl = https://example.com/r%d.git\n", i, i)
+		fmt.Fprintf(&buf, "\tfetch = +refs/heads/*:refs/remotes/r%d/* ; comment\n", i)
+	}
+	return buf.Bytes()
+}
+
+// BenchmarkValidateLarge measures decoding a large input on its own, as
+// Validate does not build a map.
+func BenchmarkValidateLarge(b *testing.B) {
+	input := largeConfig(10000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_ = Validate(input)
+	}
+}
+
+func BenchmarkParseLarge(b *testing.B) {
+	input := largeConfig(10000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_, _, _ = Parse(input)
+	}
+}
+
+func benchmarkValue(b *testing.B, length int) {
+	input := []byte("[core]\n\tvalue = \"" + strings.Repeat("abc \\t", length/5) + "\"\n")
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_, _, _ = Parse(input)
+	}
+}
+
+func BenchmarkParseValueSmall(b *testing.B) {
+	benchmarkValue(b, 50)
+}
+
+func BenchmarkParseValueLarge(b *testing.B) {
+	benchmarkValue(b, 50000)
+}
+
+// longLine is a config with a single value line of n bytes, and a header
+// and a variable name of n/4 bytes each.
+func longLine(n int) []byte {
+	value := strings.Repeat("a b\\t", n/5)
+	return []byte("[remote \"" + strings.Repeat("x", n/4) + "\"]\n\t" +
+		strings.Repeat("k", n/4) + " = " + value + "\n")
+}
+
+func TestLongLines(t *testing.T) {
+	n := 4 << 20
+	input := longLine(n)
+	key := "remote." + strings.Repeat("x", n/4) + "." + strings.Repeat("k", n/4)
+	expected := strings.Repeat("a b\t", n/5)
+	config, lineno, err := Parse(input)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, int(lineno))
+	assert.Equal(t, 1, len(config))
+	assert.True(t, config[key] == expected)
+
+	config, _, err = ParseReader(bytes.NewReader(input))
+	assert.Equal(t, nil, err)
+	assert.True(t, config[key] == expected)
+}
+
+func benchmarkLongLine(b *testing.B, n int) {
+	input := longLine(n)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = Parse(input)
+	}
+}
+
+func BenchmarkParseLongLine1M(b *testing.B) {
+	benchmarkLongLine(b, 1<<20)
+}
+
+func BenchmarkParseLongLine8M(b *testing.B) {
+	benchmarkLongLine(b, 8<<20)
+}
+
 func BenchmarkParse(b *testing.B) {
 	gitconfig := "configs/danyel.gitconfig"
 	bytes, err := ioutil.ReadFile(gitconfig)
@@ -80,3 +935,592 @@ func BenchmarkParse(b *testing.B) {
 		_, _, _ = Parse(bytes)
 	}
 }
+
+func TestLimits(t *testing.T) {
+	validConfig := "[user]\n\tname = Danyel\n\temail = cydrop@gmail.com\n"
+	_, _, err := Parse([]byte(validConfig), MaxBytes(int64(len(validConfig))), MaxLines(3))
+	assert.Equal(t, nil, err)
+
+	config, _, err := Parse([]byte(validConfig), MaxBytes(20))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	var perr *ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, 2, int(perr.Line))
+	}
+	assert.Equal(t, map[string]string{}, config)
+
+	config, lineno, err := Parse([]byte(validConfig), MaxLines(2))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	assert.Equal(t, 3, int(lineno))
+	assert.Equal(t, map[string]string{"user.name": "Danyel"}, config)
+
+	_, _, err = ParseReader(bytes.NewBufferString(strings.Repeat("\n", 100)), MaxLines(50))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	_, _, err = Parse([]byte(strings.Repeat("\n", DefaultMaxLines+1)))
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	_, _, err = Parse([]byte(strings.Repeat("\n", DefaultMaxLines+1)), MaxLines(0))
+	assert.Equal(t, nil, err)
+
+	_, _, errs := ParseLenient([]byte(validConfig), MaxLines(1))
+	assert.Equal(t, []ParseError{{Line: 2, Column: 1, Err: ErrLimitExceeded}}, errs)
+}
+
+// cancelAfter is a context that reports context.Canceled once Err has
+// been called more than n times.
+type cancelAfter struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.n--; c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestParseContext(t *testing.T) {
+	input := largeConfig(1000)
+	expected, _, _ := Parse(input)
+	config, _, err := ParseContext(context.Background(), input)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, expected, config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	config, _, err = ParseContext(ctx, []byte("[user]\n\tname = Danyel\n"))
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, config)
+
+	config, lineno, err := ParseContext(&cancelAfter{Context: context.Background(), n: 3}, input)
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, config)
+	assert.True(t, lineno > 1 && lineno < 3000, lineno)
+
+	_, _, err = ParseContext(context.Background(), []byte("[user\n"))
+	assert.True(t, errors.Is(err, ErrSectionNewLine))
+}
+
+func TestRejectDuplicateSections(t *testing.T) {
+	validConfig := `[core]
+	bare = false
+[remote "origin"]
+	url = a
+[remote "Origin"]
+	url = b
+[core]
+	editor = vim`
+	config, _, err := Parse([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "vim", config["core.editor"])
+
+	_, _, err = Parse([]byte(validConfig), RejectDuplicateSections())
+	var perr *ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, ErrDuplicateSection, perr.Err)
+		assert.Equal(t, 7, int(perr.Line))
+		assert.Equal(t, 1, int(perr.Column))
+	}
+
+	_, _, errs := ParseLenient([]byte(validConfig+"\n[remote \"origin\"]\n"), RejectDuplicateSections())
+	assert.Equal(t, []ParseError{
+		{Line: 7, Column: 1, Err: ErrDuplicateSection},
+		{Line: 9, Column: 1, Err: ErrDuplicateSection},
+	}, errs)
+}
+
+func TestLegacySubsectionCase(t *testing.T) {
+	validConfig := `[url "GitHub"]
+	insteadOf = a
+[url.GitHub]
+	insteadOf = b
+[URL.Sub.Dotted]
+	x = c
+[Url "Sub.Dotted"]
+	x = d`
+	config, _, err := Parse([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"url.GitHub.insteadof": "a",
+		"url.github.insteadof": "b",
+		"url.sub.dotted.x":     "c",
+		"url.Sub.Dotted.x":     "d",
+	}, config)
+
+	tree, _, err := ParseTree([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"GitHub", "Sub.Dotted", "github", "sub.dotted"}, sortedKeys(tree.Sections["url"]))
+
+	// Lookups follow the same rules: the subsection is matched exactly.
+	assert.Equal(t, "a", Get(config, "URL.GitHub.insteadOf", ""))
+	assert.Equal(t, "b", Get(config, "url.github.insteadOf", ""))
+}
+
+func TestLegacySections(t *testing.T) {
+	validConfig := `[remote "origin"]
+	url = a
+	fetch = x
+[Remote.Origin]
+	url = b
+[remote "Origin"]
+	url = c`
+	config, _, err := Parse([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"remote.origin.url":   "b",
+		"remote.origin.fetch": "x",
+		"remote.Origin.url":   "c",
+	}, config)
+
+	_, _, err = Parse([]byte(validConfig), RejectLegacySections())
+	var perr *ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, ErrLegacySectionSyntax, perr.Err)
+		assert.Equal(t, 4, int(perr.Line))
+		assert.Equal(t, 8, int(perr.Column))
+	}
+
+	// Dots inside a quoted subsection are not the legacy form.
+	config, _, err = Parse([]byte("[url \"git@example.com:\"]\n\tinsteadOf = x\n"), RejectLegacySections())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "x", config["url.git@example.com:.insteadof"])
+	_, _, err = Parse([]byte("[http \"https://a.b\"]\n\tsslVerify\n"), RejectLegacySections())
+	assert.Equal(t, nil, err)
+}
+
+func TestKeepCase(t *testing.T) {
+	validConfig := `[Core]
+	fileMode = false
+[Remote "Origin"]
+	URL = https://example.com/repo.git
+[Legacy.Sub]
+	Key = value`
+	config, _, err := Parse([]byte(validConfig), KeepCase())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"Core.fileMode":     "false",
+		"Remote.Origin.URL": "https://example.com/repo.git",
+		"Legacy.Sub.Key":    "value",
+	}, config)
+	assert.Equal(t, "", Get(config, "core.filemode", ""))
+	assert.Equal(t, "false", Get(config, "core.filemode", "", FoldSubsection()))
+
+	config, _, err = Parse([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"core.filemode":     "false",
+		"remote.Origin.url": "https://example.com/repo.git",
+		"legacy.sub.key":    "value",
+	}, config)
+}
+
+func TestLenientEscapes(t *testing.T) {
+	input := "[core]\n\tpath = C:\\q\\tmp\\dir\n\tlong = a\\\n\tb\n\tend = x\\"
+	_, _, err := Parse([]byte(input))
+	var perr *ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, ErrInvalidEscapeSequence, perr.Err)
+		assert.Equal(t, 2, int(perr.Line))
+		assert.Equal(t, 12, int(perr.Column))
+	}
+
+	config, _, err := Parse([]byte(input), LenientEscapes())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"core.path": "C:\\q\tmp\\dir",
+		"core.long": "a b",
+		"core.end":  "x",
+	}, config)
+
+	config, _, err = Parse([]byte("[core]\n\tlong = a\\\n\tb\n"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a b", config["core.long"])
+
+	_, _, err = Parse([]byte("[core]\n\tx = \\xzz\n"), LenientEscapes(), HexEscapes())
+	assert.ErrorIs(t, err, ErrInvalidEscapeSequence)
+}
+
+func TestNoInlineComments(t *testing.T) {
+	input := "[core] ; header comment\n" +
+		"# comment line\n" +
+		"\thash = a#b\n" +
+		"\tsemi = a ; b  \n" +
+		"\tquoted = \"x # y\" # z\n" +
+		"  ; indented comment line\n" +
+		"\tempty = # not a comment\n"
+	config, _, err := Parse([]byte(input), NoInlineComments())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"core.hash":   "a#b",
+		"core.semi":   "a ; b",
+		"core.quoted": "x # y # z",
+		"core.empty":  "# not a comment",
+	}, config)
+
+	config, _, err = Parse([]byte(input))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"core.hash":   "a",
+		"core.semi":   "a",
+		"core.quoted": "x # y",
+		"core.empty":  "",
+	}, config)
+}
+
+func TestSlashComments(t *testing.T) {
+	input := "// leading comment\n" +
+		"[core] // header comment\n" +
+		"  // indented comment\n" +
+		"\teditor = vi // editor\n" +
+		"\tpath = a/b//c\n" +
+		"\turl = \"https://example.com\" //x\n" +
+		"\tbare = https://example.com\n" +
+		"\tslash = /\n" +
+		"\tend = x/"
+	config, lineno, err := Parse([]byte(input), SlashComments())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 9, int(lineno))
+	assert.Equal(t, map[string]string{
+		"core.editor": "vi",
+		"core.path":   "a/b",
+		"core.url":    "https://example.com",
+		"core.bare":   "https:",
+		"core.slash":  "/",
+		"core.end":    "x/",
+	}, config)
+
+	// Without the option "//" is an invalid key, and part of values.
+	_, _, err = Parse([]byte(input))
+	assert.ErrorIs(t, err, ErrInvalidKeyChar)
+	config, _, err = Parse([]byte("[core]\n\tpath = a/b//c\n"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a/b//c", config["core.path"])
+
+	var perr *ParseError
+	_, _, err = Parse([]byte("[core]\n\t/x = y\n"), SlashComments())
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, ErrInvalidKeyChar, perr.Err)
+		assert.Equal(t, 2, int(perr.Line))
+		assert.Equal(t, 2, int(perr.Column))
+	}
+
+	tokens := scanAll(NewScanner([]byte("// a\n[core] //b\n\tx = 1 // c\n"), SlashComments()))
+	assert.Equal(t, []scanned{
+		{TokenComment, "// a", 1},
+		{TokenSection, "core", 2},
+		{TokenComment, "//b", 2},
+		{TokenKey, "x", 3},
+		{TokenValue, "1", 3},
+		{TokenComment, "// c", 3},
+		{TokenEOF, "", 3},
+	}, tokens)
+	s := NewScanner([]byte("[core]\n\t/x = y\n"), SlashComments())
+	assert.Equal(t, TokenError, scanAll(s)[1].typ)
+	assert.Equal(t, err, s.Err())
+}
+
+func TestDisableComments(t *testing.T) {
+	input := "[core]\n" +
+		"\tcolor = #ff0000 ; red\n" +
+		"\tlist = a;b # c\n"
+	tests := []struct {
+		opts []Option
+		want map[string]string
+	}{
+		{nil, map[string]string{"core.color": "", "core.list": "a"}},
+		{[]Option{DisableHashComments()}, map[string]string{"core.color": "#ff0000", "core.list": "a"}},
+		{[]Option{DisableSemicolonComments()}, map[string]string{"core.color": "", "core.list": "a;b"}},
+		{
+			[]Option{DisableHashComments(), DisableSemicolonComments()},
+			map[string]string{"core.color": "#ff0000 ; red", "core.list": "a;b # c"},
+		},
+	}
+	for _, test := range tests {
+		config, _, err := Parse([]byte(input), test.opts...)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, test.want, config)
+		config, _, err = ParseReader(strings.NewReader(input), test.opts...)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, test.want, config)
+		// Skipped sections end their values at the same place.
+		config, _, err = Parse([]byte(input+"[x]\n\ty = z\n"), append(test.opts, WithSections("x"))...)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, map[string]string{"x.y": "z"}, config)
+	}
+
+	// The other character still starts comment lines.
+	config, _, err := Parse([]byte("; a\n[core]\n\tx = #1 ; b\n"), DisableHashComments())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{"core.x": "#1"}, config)
+	config, _, err = Parse([]byte("# a\n[core] # b\n\tx = ;1 # c\n"), DisableSemicolonComments())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{"core.x": ";1"}, config)
+
+	// A disabled character at the start of a line is an invalid key.
+	var perr *ParseError
+	_, _, err = Parse([]byte("[core]\n\t# a\n"), DisableHashComments())
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, ErrInvalidKeyChar, perr.Err)
+		assert.Equal(t, 2, int(perr.Line))
+		assert.Equal(t, 2, int(perr.Column))
+	}
+	_, _, err = Parse([]byte("; a\n"), DisableSemicolonComments())
+	assert.ErrorIs(t, err, ErrInvalidKeyChar)
+
+	tokens := scanAll(NewScanner([]byte("; a\n[core]\n\tx = #1 ; b\n"), DisableHashComments()))
+	assert.Equal(t, []scanned{
+		{TokenComment, "; a", 1},
+		{TokenSection, "core", 2},
+		{TokenKey, "x", 3},
+		{TokenValue, "#1", 3},
+		{TokenComment, "; b", 3},
+		{TokenEOF, "", 3},
+	}, tokens)
+}
+
+func TestRawValues(t *testing.T) {
+	input := "[core]\n" +
+		"\tpath = \"C:\\\\dir\" \\t # comment\n" +
+		"\ttab = a\\tb\n" +
+		"\tspaced =   a \t b   \n" +
+		"\tquoted = \"  a\\\"; b  \" ; c\n" +
+		"\tempty = \"\"\n" +
+		"\tblank =   \n" +
+		"\tcontinued = one \\\r\n" +
+		"  two\n" +
+		"\tbad = \\q\n" +
+		"\tend = x\\"
+	config, _, err := Parse([]byte(input), RawValues())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"core.path":      `"C:\\dir" \t`,
+		"core.tab":       `a\tb`,
+		"core.spaced":    "a \t b",
+		"core.quoted":    `"  a\"; b  "`,
+		"core.empty":     `""`,
+		"core.blank":     "",
+		"core.continued": "one \\\n  two",
+		"core.bad":       `\q`,
+		"core.end":       `x\`,
+	}, config)
+
+	// Decoding the raw value gives the default result.
+	delete(config, "core.bad")
+	decoded, _, err := Parse([]byte(strings.Replace(input, "\tbad = \\q\n", "", 1)))
+	assert.Equal(t, nil, err)
+	for key, value := range config {
+		again, _, err := Parse([]byte("[a]\n\tk = " + value + "\n"))
+		assert.Equal(t, nil, err, key)
+		assert.Equal(t, decoded[key], again["a.k"], key)
+	}
+
+	_, _, err = Parse([]byte("[core]\n\tk = \"open\n"), RawValues())
+	assert.ErrorIs(t, err, ErrUnfinishedQuote)
+}
+
+func TestWithSections(t *testing.T) {
+	input := "[user]\n" +
+		"\tname = Danyel\n" +
+		"\tna@me = \\q skipped, so not validated\n" +
+		"[Remote \"origin\"]\n" +
+		"\turl = a \\\n" +
+		"  b\n" +
+		"[core]\n" +
+		"\teditor = \"vi ; \\\n" +
+		"[remote \"x\"]\"\n" +
+		"\tpager = less ; comment \\\n" +
+		"[branch \"main\"]\n" +
+		"\tremote = origin\n"
+	config, lineno, err := Parse([]byte(input), WithSections("remote"), WithSections("BRANCH"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 12, int(lineno))
+	assert.Equal(t, map[string]string{
+		"remote.origin.url":  "a   b",
+		"branch.main.remote": "origin",
+	}, config)
+
+	config, _, err = Parse([]byte(input), WithSections())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{}, config)
+
+	// Skipped values end where they would end if they were parsed.
+	input = "[core]\n\tx = a # b \\\n[remote \"a\"]\n\ty = a // b \\\n[remote \"b\"]\n\tz = 1\n"
+	for _, opts := range [][]Option{nil, {NoInlineComments()}, {SlashComments()}} {
+		expected, lineno, err := Parse([]byte(input), opts...)
+		assert.Equal(t, nil, err)
+		config, filteredLineno, err := Parse([]byte(input), append(opts, WithSections("remote"))...)
+		assert.Equal(t, nil, err)
+		for key := range expected {
+			if !strings.HasPrefix(key, "remote.") {
+				delete(expected, key)
+			}
+		}
+		assert.Equal(t, expected, config)
+		assert.Equal(t, lineno, filteredLineno)
+	}
+
+	// Headers are still checked.
+	_, _, err = Parse([]byte("[user]\n\tname = x\n[core\n"), WithSections("remote"))
+	var perr *ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, ErrSectionNewLine, perr.Err)
+		assert.Equal(t, 3, int(perr.Line))
+	}
+
+	large := strings.Repeat("[user]\n\tname = Danyel\n\temail = cydrop@gmail.com\n", 100) + "[remote \"origin\"]\n\turl = x\n"
+	all := testing.AllocsPerRun(10, func() { _, _, _ = Parse([]byte(large)) })
+	some := testing.AllocsPerRun(10, func() { _, _, _ = Parse([]byte(large), WithSections("remote")) })
+	assert.Less(t, some, all/2)
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	for _, test := range []struct {
+		input        string
+		line, column int
+		offset       int
+	}{
+		{"[user]\n\tname = caf\xc3", 2, 12, 18},
+		{"[user]\n\tname = caf\xc3\n", 2, 12, 18},
+		{"[user]\n\tname = \xe2\x82 x\n", 2, 9, 15},
+		{"[us\xffer]\n", 1, 4, 3},
+		{"\xef\xbb\xbf[user]\n\tna\xf0\x9f\x98me = x\n", 2, 4, 13},
+		{"[a]\n\tb = \"\xed\xa0\x80\"\n", 2, 7, 10},
+	} {
+		_, _, err := Parse([]byte(test.input))
+		assert.False(t, errors.Is(err, ErrInvalidUTF8), test.input)
+
+		for _, parse := range []func() error{
+			func() error { _, _, err := Parse([]byte(test.input), RejectInvalidUTF8()); return err },
+			func() error {
+				_, _, err := ParseReader(strings.NewReader(test.input), RejectInvalidUTF8())
+				return err
+			},
+		} {
+			err := parse()
+			assert.ErrorIs(t, err, ErrInvalidUTF8, test.input)
+			var perr *ParseError
+			if assert.True(t, errors.As(err, &perr), test.input) {
+				assert.Equal(t, test.line, int(perr.Line), test.input)
+				assert.Equal(t, test.column, int(perr.Column), test.input)
+				assert.Equal(t, ErrInvalidUTF8, perr.Err, test.input)
+				assert.Equal(t, fmt.Sprintf("at byte offset %d", test.offset), perr.Detail, test.input)
+				assert.Contains(t, err.Error(), perr.Detail, test.input)
+			}
+		}
+	}
+
+	config, _, err := Parse([]byte("[user]\n\tname = caf\xc3\n"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "caf\uFFFD", config["user.name"])
+	config, _, err = Parse([]byte("[user]\n\tname = café \uFFFD\n"), RejectInvalidUTF8())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "café \uFFFD", config["user.name"])
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"[user]\n\tname = Danyel\n\temail = cydrop@gmail.com\n",
+		"[remote \"origin\"]\r\n\turl = https://example.com/repo.git\r\n\tfetch = +refs/heads/*:refs/remotes/origin/*\r\n",
+		"[core]\r\tbare\r\teditor = \"subl -w\" ; comment\r",
+		"\xef\xbb\xbf[a.b]\n\tc = \" x \"\\\n  y # z\n",
+		"[a]\n\tb = \\t\\n\\b\\\\\\\"\x07\x1b\xc3\xa9\n",
+		"[a \"q\\\"u\\\\o\"] k = \"#;\"",
+		"[a]\n\tb = \"unterminated\n",
+		"[a]\n\tna@me = x\n",
+		"[a]\n\tb = \"x\u00a0y\u0085z\u2028w\"\n\tc = x\u3000y\n",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		config, _, err := Parse(b)
+		if err != nil {
+			return
+		}
+		// Marshal cannot write keys below a header with an empty section
+		// name ([ "sub"]), which git accepts as well.
+		for key := range config {
+			if _, err := NormalizeKey(key); err != nil {
+				return
+			}
+		}
+		out, err := Marshal(config)
+		if err != nil {
+			t.Fatalf("Marshal(%q): %v", config, err)
+		}
+		again, _, err := Parse(out)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", out, err)
+		}
+		if !reflect.DeepEqual(config, again) {
+			t.Fatalf("round trip of %q changed %q to %q", b, config, again)
+		}
+	})
+}
+
+func TestCheckSpacing(t *testing.T) {
+	validConfig := "[core]\n" +
+		"\teditor = vim\n" +
+		"\tpager=less\n" +
+		"\tbare\n" +
+		"\tautocrlf  =  input\n" +
+		"\tfilemode =\tfalse\n" +
+		"\tempty =\n" +
+		"\tspaced = \n" +
+		"\tcomment = ; nothing\n" +
+		"[user] name =Danyel"
+	var warnings []SpacingWarning
+	config, _, err := Parse([]byte(validConfig), CheckSpacing(func(w SpacingWarning) {
+		warnings = append(warnings, w)
+	}))
+	assert.Equal(t, nil, err)
+	expected, _, _ := Parse([]byte(validConfig))
+	assert.Equal(t, expected, config)
+	assert.Equal(t, []SpacingWarning{
+		{Line: 3, Column: 7, Got: "=", Want: " = "},
+		{Line: 5, Column: 12, Got: "  =  ", Want: " = "},
+		{Line: 6, Column: 11, Got: " =\t", Want: " = "},
+		{Line: 8, Column: 9, Got: " = ", Want: " ="},
+		{Line: 10, Column: 13, Got: " =", Want: " = "},
+	}, warnings)
+	assert.Equal(t, `line 3, column 7: spacing "=", want " = "`, warnings[0].String())
+}
+
+func TestOnComplete(t *testing.T) {
+	input := "\xef\xbb\xbf[core]\n\teditor = vi\n\teditor = vim\n[user]\n\tname = x\n"
+	var stats []ParseStats
+	record := OnComplete(func(s ParseStats) { stats = append(stats, s) })
+
+	config, lineno, err := Parse([]byte(input), record)
+	assert.Equal(t, nil, err)
+	assert.Len(t, config, 2)
+	_, _, err = ParseReader(strings.NewReader(input), record)
+	assert.Equal(t, nil, err)
+	multi, _, err := ParseMulti([]byte(input), record)
+	assert.Equal(t, nil, err)
+	assert.Len(t, multi["core.editor"], 2)
+	if assert.Len(t, stats, 3) {
+		for _, s := range stats {
+			assert.Equal(t, lineno, s.Lines)
+			assert.Equal(t, 3, s.Keys)
+			assert.Equal(t, int64(len(input)), s.Bytes)
+			assert.True(t, s.Duration >= 0)
+			assert.Equal(t, nil, s.Err)
+		}
+	}
+
+	stats = nil
+	_, lineno, err = Parse([]byte("[core]\n\tbare\n\tna@me = x\n"), record)
+	if assert.Len(t, stats, 1) {
+		assert.Equal(t, err, stats[0].Err)
+		assert.Equal(t, lineno, stats[0].Lines)
+		assert.Equal(t, 1, stats[0].Keys)
+	}
+	stats = nil
+	_, _, err = Parse([]byte("[a]\n\tb = c\n\td = e\n"), MaxLines(2), record)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	if assert.Len(t, stats, 1) {
+		assert.Equal(t, err, stats[0].Err)
+	}
+}
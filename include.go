@@ -0,0 +1,231 @@
+package goconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ParseWithIncludes parses the file at path and follows include.path
+// directives like git does: the included file is parsed in place of the
+// directive, so its values override those set before it and are overridden
+// by those set after it. Relative include paths are resolved against the
+// directory of the including file, and ~ is expanded as in GetPath.
+// Included files that do not exist are skipped. An include cycle returns
+// ErrIncludeCycle, nesting deeper than MaxIncludeDepth ErrIncludeDepth.
+//
+// includeIf.<condition>.path directives are followed only if the condition
+// matches the repository given by WithIncludeContext. The implemented
+// conditions are gitdir:, gitdir/i: (case-insensitive) and onbranch:, with
+// git's pattern rules: ** matches any number of directories, a trailing /
+// matches everything below it, a leading ./ is relative to the including
+// file, and a pattern that is neither absolute nor starts with ~/ or ./
+// matches at any depth. Other conditions, such as hasconfig:, never match.
+func ParseWithIncludes(path string, opts ...Option) (map[string]string, error) {
+	cfg := map[string]string{}
+	o := newOptions(opts)
+	inc := &includer{opts: opts, maxDepth: o.maxIncludeDepth, ctx: o.includeContext}
+	err := inc.parseFile(path, func(name, key, value string) error {
+		cfg[name+key] = value
+		return nil
+	})
+	return cfg, err
+}
+
+// ParseLayers parses the files at paths in order into one map, like git
+// layers the system, global and local config: a key set in a later file
+// overrides the same key in earlier ones. Each file follows include.path
+// directives as in ParseWithIncludes with the default options. Files that
+// do not exist are skipped; a file that exists but cannot be read or parsed
+// stops the parse with an error naming it.
+func ParseLayers(paths ...string) (map[string]string, error) {
+	o := newOptions(nil)
+	inc := &includer{maxDepth: o.maxIncludeDepth}
+	return inc.parseLayers(paths)
+}
+
+type includer struct {
+	opts     []Option
+	maxDepth int
+	ctx      IncludeContext
+	// active holds the files currently being parsed, outermost first.
+	active []string
+}
+
+// parseLayers parses the files at paths as described for ParseLayers.
+func (inc *includer) parseLayers(paths []string) (map[string]string, error) {
+	cfg := map[string]string{}
+	for _, path := range paths {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		err := inc.parseFile(path, func(name, key, value string) error {
+			cfg[name+key] = value
+			return nil
+		})
+		if err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+func (inc *includer) parseFile(path string, set setter) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("goconfig: %w", err)
+	}
+	for _, active := range inc.active {
+		if active == abs {
+			return fmt.Errorf("%w: %s", ErrIncludeCycle, path)
+		}
+	}
+	if len(inc.active) > inc.maxDepth {
+		return fmt.Errorf("%w: %s", ErrIncludeDepth, path)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("goconfig: %w", err)
+	}
+
+	inc.active = append(inc.active, abs)
+	defer func() { inc.active = inc.active[:len(inc.active)-1] }()
+	_, err = parseBytes(b, func(name, key, value string) error {
+		if err := set(name, key, value); err != nil {
+			return err
+		}
+		if key != "path" {
+			return nil
+		}
+		section, subsection := splitSection(name)
+		switch {
+		case section == "include" && subsection == "":
+		case section == "includeif" && inc.matches(path, subsection):
+		default:
+			return nil
+		}
+		return inc.include(path, value, set)
+	}, inc.opts)
+	if perr, ok := err.(*ParseError); ok {
+		return fmt.Errorf("%s: %w", path, perr)
+	}
+	return err
+}
+
+func (inc *includer) include(from, value string, set setter) error {
+	if value == "" {
+		return nil
+	}
+	path, err := expandPath(value, homeDir)
+	if err != nil {
+		return fmt.Errorf("%w in %s: %v", ErrInvalidPath, from, err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(from), path)
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return inc.parseFile(path, set)
+}
+
+// matches reports whether the includeIf condition cond, found in the file
+// from, holds for the include context.
+func (inc *includer) matches(from, cond string) bool {
+	kind, pattern, ok := strings.Cut(cond, ":")
+	if !ok {
+		return false
+	}
+	switch kind {
+	case "gitdir", "gitdir/i":
+		if inc.ctx.GitDir == "" {
+			return false
+		}
+		pattern, ok := gitdirPattern(from, pattern)
+		if !ok {
+			return false
+		}
+		gitdir := filepath.ToSlash(filepath.Clean(inc.ctx.GitDir))
+		return wildmatch(pattern, gitdir, kind == "gitdir/i")
+	case "onbranch":
+		if inc.ctx.Branch == "" {
+			return false
+		}
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+		return wildmatch(pattern, inc.ctx.Branch, false)
+	}
+	return false
+}
+
+// gitdirPattern turns the pattern of a gitdir: condition into an absolute
+// wildmatch pattern.
+func gitdirPattern(from, pattern string) (string, bool) {
+	switch {
+	case strings.HasPrefix(pattern, "~/"):
+		expanded, err := expandPath(pattern, homeDir)
+		if err != nil {
+			return "", false
+		}
+		pattern = filepath.ToSlash(expanded)
+	case strings.HasPrefix(pattern, "./"):
+		dir, err := filepath.Abs(filepath.Dir(from))
+		if err != nil {
+			return "", false
+		}
+		pattern = filepath.ToSlash(dir) + pattern[1:]
+	case !filepath.IsAbs(pattern) && !strings.HasPrefix(pattern, "/"):
+		pattern = "**/" + pattern
+	}
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return pattern, true
+}
+
+// wildmatch matches name against a glob pattern in which * and ? do not
+// match a slash, ** matches across slashes and "**/" matches zero or more
+// directories.
+func wildmatch(pattern, name string, fold bool) bool {
+	var expr strings.Builder
+	if fold {
+		expr.WriteString("(?i)")
+	}
+	expr.WriteByte('^')
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	expr.WriteByte('$')
+	re, err := regexp.Compile(expr.String())
+	return err == nil && re.MatchString(name)
+}
@@ -0,0 +1,214 @@
+package goconfig
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigs(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParseWithIncludes(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"main.gitconfig": `[user]
+	name = Main
+	email = main@example.com
+[include]
+	path = sub/local.gitconfig
+	path = missing.gitconfig
+[core]
+	editor = vim`,
+		"sub/local.gitconfig": `[user]
+	email = local@example.com
+[core]
+	editor = emacs
+	pager = less
+[include]
+	path = nested.gitconfig`,
+		"sub/nested.gitconfig": `[core]
+	pager = more`,
+	})
+	config, err := ParseWithIncludes(filepath.Join(dir, "main.gitconfig"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Main", config["user.name"])
+	assert.Equal(t, "local@example.com", config["user.email"])
+	assert.Equal(t, "vim", config["core.editor"])
+	assert.Equal(t, "more", config["core.pager"])
+}
+
+func TestParseWithIncludesHome(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"main.gitconfig":   "[include]\n\tpath = ~/.gitconfig.local\n",
+		".gitconfig.local": "[user]\n\tname = Local\n",
+	})
+	t.Setenv("HOME", dir)
+	config, err := ParseWithIncludes(filepath.Join(dir, "main.gitconfig"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Local", config["user.name"])
+}
+
+func TestParseWithIncludesCycle(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"a.gitconfig": "[include]\n\tpath = b.gitconfig\n",
+		"b.gitconfig": "[include]\n\tpath = a.gitconfig\n",
+	})
+	_, err := ParseWithIncludes(filepath.Join(dir, "a.gitconfig"))
+	assert.ErrorIs(t, err, ErrIncludeCycle)
+}
+
+func TestParseWithIncludesDepth(t *testing.T) {
+	files := map[string]string{}
+	for i := 0; i < 5; i++ {
+		files[strconv.Itoa(i)] = "[include]\n\tpath = " + strconv.Itoa(i+1) + "\n"
+	}
+	files["5"] = "[user]\n\tname = Deep\n"
+	dir := writeConfigs(t, files)
+
+	config, err := ParseWithIncludes(filepath.Join(dir, "0"), MaxIncludeDepth(5))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Deep", config["user.name"])
+
+	_, err = ParseWithIncludes(filepath.Join(dir, "0"), MaxIncludeDepth(4))
+	assert.ErrorIs(t, err, ErrIncludeDepth)
+}
+
+func TestParseWithIncludesErrors(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"main.gitconfig":   "[include]\n\tpath = broken.gitconfig\n",
+		"broken.gitconfig": "[user]\n\tna@me = x\n",
+	})
+	_, err := ParseWithIncludes(filepath.Join(dir, "main.gitconfig"))
+	var perr *ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 2, int(perr.Line))
+	assert.Contains(t, err.Error(), "broken.gitconfig")
+
+	_, err = ParseWithIncludes(filepath.Join(dir, "none.gitconfig"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestParseLayers(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"system": "[core]\n\teditor = vi\n\tpager = less\n[user]\n\tname = System\n",
+		"global": "[user]\n\tname = Global\n\temail = global@example.com\n[include]\n\tpath = extra\n",
+		"extra":  "[core]\n\tpager = more\n",
+		"local":  "[user]\n\temail = local@example.com\n",
+		"broken": "[user\n",
+	})
+	system, global, local := filepath.Join(dir, "system"), filepath.Join(dir, "global"), filepath.Join(dir, "local")
+	config, err := ParseLayers(system, filepath.Join(dir, "missing"), global, local)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"core.editor":  "vi",
+		"core.pager":   "more",
+		"user.name":    "Global",
+		"user.email":   "local@example.com",
+		"include.path": "extra",
+	}, config)
+
+	config, err = ParseLayers()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{}, config)
+
+	_, err = ParseLayers(system, filepath.Join(dir, "broken"), local)
+	var perr *ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Contains(t, err.Error(), "broken")
+}
+
+func TestParseWithIncludeIf(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"main.gitconfig": `[user]
+	email = private@example.com
+[includeIf "gitdir:~/work/"]
+	path = work.gitconfig
+[includeIf "gitdir/i:~/CLIENT/**/.git"]
+	path = client.gitconfig
+[includeIf "gitdir:./repos/local/.git"]
+	path = local.gitconfig
+[includeIf "gitdir:oss/"]
+	path = oss.gitconfig
+[includeIf "onbranch:release/"]
+	path = release.gitconfig
+[includeIf "hasconfig:remote.*.url:https://example.com/**"]
+	path = hasconfig.gitconfig`,
+		"work.gitconfig":      "[user]\n\temail = work@example.com\n",
+		"client.gitconfig":    "[user]\n\tname = Client\n",
+		"local.gitconfig":     "[core]\n\teditor = local\n",
+		"oss.gitconfig":       "[core]\n\tpager = oss\n",
+		"release.gitconfig":   "[push]\n\tdefault = release\n",
+		"hasconfig.gitconfig": "[user]\n\tsigningkey = hasconfig\n",
+	})
+	t.Setenv("HOME", "/home/danyel")
+	path := filepath.Join(dir, "main.gitconfig")
+
+	config, err := ParseWithIncludes(path)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"user.email":                                                   "private@example.com",
+		"includeif.gitdir:~/work/.path":                                "work.gitconfig",
+		"includeif.gitdir/i:~/CLIENT/**/.git.path":                     "client.gitconfig",
+		"includeif.gitdir:./repos/local/.git.path":                     "local.gitconfig",
+		"includeif.gitdir:oss/.path":                                   "oss.gitconfig",
+		"includeif.onbranch:release/.path":                             "release.gitconfig",
+		"includeif.hasconfig:remote.*.url:https://example.com/**.path": "hasconfig.gitconfig",
+	}, config)
+
+	tests := []struct {
+		ctx      IncludeContext
+		key      string
+		expected string
+	}{
+		{IncludeContext{GitDir: "/home/danyel/work/project/.git"}, "user.email", "work@example.com"},
+		{IncludeContext{GitDir: "/home/danyel/workshop/.git"}, "user.email", "private@example.com"},
+		{IncludeContext{GitDir: "/home/danyel/client/a/b/.git"}, "user.name", "Client"},
+		{IncludeContext{GitDir: "/home/danyel/Client/.git"}, "user.name", "Client"},
+		{IncludeContext{GitDir: filepath.Join(dir, "repos/local/.git")}, "core.editor", "local"},
+		{IncludeContext{GitDir: "/src/github/oss/tool/.git"}, "core.pager", "oss"},
+		{IncludeContext{Branch: "release/1.0"}, "push.default", "release"},
+		{IncludeContext{Branch: "main"}, "push.default", ""},
+	}
+	for _, test := range tests {
+		config, err := ParseWithIncludes(path, WithIncludeContext(test.ctx))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, test.expected, config[test.key], test.ctx)
+		assert.NotContains(t, config, "user.signingkey")
+	}
+}
+
+func TestWildmatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		expected      bool
+	}{
+		{"/a/*/c", "/a/b/c", true},
+		{"/a/*/c", "/a/b/x/c", false},
+		{"/a/**/c", "/a/c", true},
+		{"/a/**/c", "/a/b/x/c", true},
+		{"/a/**", "/a/b/x/c", true},
+		{"/a/?", "/a/b", true},
+		{"/a/[bc]", "/a/c", true},
+		{"/a/[!bc]", "/a/c", false},
+		{"/a.b", "/aXb", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, wildmatch(test.pattern, test.name, false), test.pattern+" "+test.name)
+	}
+}
@@ -0,0 +1,58 @@
+package goconfig
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ToJSON converts cfg to a JSON object nested by section, subsection and
+// variable. Variables without subsection are members of the section object:
+//
+//	{"remote": {"origin": {"url": "..."}, "pushdefault": "origin"}}
+//
+// Object members are sorted, so the output is stable. If a variable and a
+// subsection of the same section share a name, ToJSON returns
+// ErrKeyConflict.
+func ToJSON(cfg map[string]string) ([]byte, error) {
+	multi := make(map[string][]string, len(cfg))
+	for key, value := range cfg {
+		multi[key] = []string{value}
+	}
+	return ToJSONMulti(multi)
+}
+
+// ToJSONMulti works like ToJSON for a configuration as returned by
+// ParseMulti. Keys with more than one value become JSON arrays.
+func ToJSONMulti(cfg map[string][]string) ([]byte, error) {
+	root := map[string]map[string]interface{}{}
+	for key, values := range cfg {
+		section, subsection, name, err := splitFlatKey(key)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{} = values
+		if len(values) == 1 {
+			value = values[0]
+		}
+		if root[section] == nil {
+			root[section] = map[string]interface{}{}
+		}
+		parent := root[section]
+		if subsection != "" {
+			sub, ok := parent[subsection].(map[string]interface{})
+			if !ok {
+				if _, exists := parent[subsection]; exists {
+					return nil, fmt.Errorf("%w: %s", ErrKeyConflict, key)
+				}
+				sub = map[string]interface{}{}
+				parent[subsection] = sub
+			}
+			parent = sub
+		}
+		if _, isSub := parent[name].(map[string]interface{}); isSub {
+			return nil, fmt.Errorf("%w: %s", ErrKeyConflict, key)
+		}
+		parent[name] = value
+	}
+	return json.Marshal(root)
+}
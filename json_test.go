@@ -0,0 +1,49 @@
+package goconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToJSON(t *testing.T) {
+	config, _, err := Parse([]byte(`[user]
+	name = Danyel
+	email = cydrop@gmail.com
+[remote "origin"]
+	url = https://example.com/repo.git
+[remote]
+	pushDefault = origin
+[http "https://my-website.com"]
+	sslVerify = false`))
+	assert.Equal(t, nil, err)
+	out, err := ToJSON(config)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"http":{"https://my-website.com":{"sslverify":"false"}},`+
+		`"remote":{"origin":{"url":"https://example.com/repo.git"},"pushdefault":"origin"},`+
+		`"user":{"email":"cydrop@gmail.com","name":"Danyel"}}`, string(out))
+
+	for i := 0; i < 10; i++ {
+		again, _ := ToJSON(config)
+		assert.Equal(t, out, again)
+	}
+}
+
+func TestToJSONMulti(t *testing.T) {
+	config, _, err := ParseMulti([]byte(`[remote "origin"]
+	url = https://example.com/repo.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	fetch = +refs/tags/*:refs/tags/*`))
+	assert.Equal(t, nil, err)
+	out, err := ToJSONMulti(config)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"remote":{"origin":{"fetch":["+refs/heads/*:refs/remotes/origin/*",`+
+		`"+refs/tags/*:refs/tags/*"],"url":"https://example.com/repo.git"}}}`, string(out))
+}
+
+func TestToJSONConflict(t *testing.T) {
+	_, err := ToJSON(map[string]string{"remote.origin": "x", "remote.origin.url": "y"})
+	assert.ErrorIs(t, err, ErrKeyConflict)
+	_, err = ToJSON(map[string]string{"nosection": "x"})
+	assert.ErrorIs(t, err, ErrInvalidKeyChar)
+}
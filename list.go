@@ -0,0 +1,67 @@
+package goconfig
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// List returns cfg in the format of git config --list: one key=value line
+// per key, sorted by key. Keys are printed as stored in the map, which for
+// maps returned by Parse means lowercase section and variable names and
+// subsections in their original case.
+//
+// A map does not tell a bare key such as "bare" in [core] from one set to
+// the empty string, so List prints both as "core.bare=", while git prints
+// a bare key as "core.bare" without '='.
+func List(cfg map[string]string) string {
+	var b strings.Builder
+	for _, key := range sortedKeys(cfg) {
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(cfg[key])
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// ListMulti works like List for a configuration as returned by ParseMulti,
+// writing one line per value in the order the values were parsed. Like
+// List, it prints bare keys with a '='.
+func ListMulti(cfg map[string][]string) string {
+	var b strings.Builder
+	for _, key := range sortedKeys(cfg) {
+		for _, value := range cfg[key] {
+			b.WriteString(key)
+			b.WriteByte('=')
+			b.WriteString(value)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// ListNull returns cfg in the format of git config --list --null: each key
+// is followed by a newline, its value and a NUL byte, sorted by key. Unlike
+// the output of List, this can be split unambiguously even if values
+// contain newlines. As in List, a bare key is printed like an empty value,
+// as "core.bare\n\x00", while git omits the newline: "core.bare\x00".
+func ListNull(cfg map[string]string) []byte {
+	var b bytes.Buffer
+	for _, key := range sortedKeys(cfg) {
+		b.WriteString(key)
+		b.WriteByte('\n')
+		b.WriteString(cfg[key])
+		b.WriteByte(0)
+	}
+	return b.Bytes()
+}
+
+func sortedKeys[V any](cfg map[string]V) []string {
+	keys := make([]string, 0, len(cfg))
+	for key := range cfg {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,62 @@
+package goconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const listConfig = `[user]
+	Name = Danyel
+[remote "Origin"]
+	URL = https://example.com/repo.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	fetch = +refs/tags/*:refs/tags/*
+[core]
+	bare`
+
+func TestList(t *testing.T) {
+	config, _, err := Parse([]byte(listConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `core.bare=
+remote.Origin.fetch=+refs/tags/*:refs/tags/*
+remote.Origin.url=https://example.com/repo.git
+user.name=Danyel
+`, List(config))
+	assert.Equal(t, "", List(nil))
+}
+
+func TestListMulti(t *testing.T) {
+	config, _, err := ParseMulti([]byte(listConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `core.bare=
+remote.Origin.fetch=+refs/heads/*:refs/remotes/origin/*
+remote.Origin.fetch=+refs/tags/*:refs/tags/*
+remote.Origin.url=https://example.com/repo.git
+user.name=Danyel
+`, ListMulti(config))
+}
+
+func TestListNull(t *testing.T) {
+	config, _, err := Parse([]byte(listConfig + "\n[alias]\n\tst = \"!git status\\n\\tgit log\"\n"))
+	assert.Equal(t, nil, err)
+	out := ListNull(config)
+	assert.Equal(t, "alias.st\n!git status\n\tgit log\x00core.bare\n\x00", string(out[:strings.Index(string(out), "remote")]))
+
+	var keys []string
+	decoded := map[string]string{}
+	for _, record := range strings.SplitAfter(string(out), "\x00") {
+		if record == "" {
+			continue
+		}
+		assert.True(t, strings.HasSuffix(record, "\x00"), record)
+		key, value, found := strings.Cut(strings.TrimSuffix(record, "\x00"), "\n")
+		assert.True(t, found, record)
+		keys = append(keys, key)
+		decoded[key] = value
+	}
+	assert.Equal(t, config, decoded)
+	assert.Equal(t, sortedKeys(config), keys)
+	assert.Equal(t, 0, len(ListNull(nil)))
+}
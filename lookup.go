@@ -0,0 +1,227 @@
+package goconfig
+
+import (
+	"regexp"
+	"strings"
+)
+
+// LookupOption changes how Get, Has and the other lookup functions match
+// keys.
+type LookupOption func(*lookupOptions)
+
+type lookupOptions struct {
+	foldSubsection bool
+	foldValue      bool
+}
+
+func newLookupOptions(opts []LookupOption) lookupOptions {
+	var o lookupOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// FoldSubsection makes the lookup match the subsection case-insensitively
+// as well, so "remote.Origin.url" finds remote.origin.url. This diverges
+// from git, which treats subsections as case-sensitive. If several keys
+// match, the exact match wins, otherwise the first one in sort order.
+func FoldSubsection() LookupOption {
+	return func(o *lookupOptions) {
+		o.foldSubsection = true
+	}
+}
+
+// FoldValue makes Equals compare the value case-insensitively, so "True"
+// equals "true". It does not affect how the key is matched.
+func FoldValue() LookupOption {
+	return func(o *lookupOptions) {
+		o.foldValue = true
+	}
+}
+
+// Get returns the value of key, or def if key is not set. The key is
+// normalized like git does before the lookup: the section (up to the first
+// dot) and the variable name (after the last dot) are lowercased, while
+// the subsection in between is matched exactly. So "User.Name" finds
+// user.name and "remote.Origin.URL" finds remote.Origin.url, but not
+// remote.origin.url.
+func Get(cfg map[string]string, key, def string, opts ...LookupOption) string {
+	if value, ok := find(cfg, key, opts); ok {
+		return value
+	}
+	return def
+}
+
+// Has reports whether key is set, normalizing it as in Get. A key that is
+// set to the empty string ("key =") is present, as is a bare key without
+// '=' (stored as "" or, with BareKeysAsTrue, as "true"); use GetBool to
+// read such keys as booleans.
+func Has(cfg map[string]string, key string, opts ...LookupOption) bool {
+	_, ok := find(cfg, key, opts)
+	return ok
+}
+
+// GetFirst returns the value of the first of keys that is set, and whether
+// any is, normalizing each key as in Get. This is the way git reads a
+// setting under its current name and falls back to a deprecated one.
+func GetFirst(cfg map[string]string, keys ...string) (string, bool) {
+	for _, key := range keys {
+		if value, ok := find(cfg, key, nil); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Equals reports whether key is set and its value is want, normalizing the
+// key as in Get. Unlike cfg[key] == want, it is false for a key that is not
+// set even if want is empty, and it is false for a nil map.
+func Equals(cfg map[string]string, key, want string, opts ...LookupOption) bool {
+	value, ok := find(cfg, key, opts)
+	if !ok {
+		return false
+	}
+	if newLookupOptions(opts).foldValue {
+		return strings.EqualFold(value, want)
+	}
+	return value == want
+}
+
+// GetAll returns every value of key in cfg, a map as returned by
+// ParseMulti, in file order, or nil if key is not set. The key is
+// normalized as in Get.
+func GetAll(cfg map[string][]string, key string, opts ...LookupOption) []string {
+	values, _ := find(cfg, key, opts)
+	return values
+}
+
+// GetLast returns the last value of key in cfg, a map as returned by
+// ParseMulti, like `git config --get` does, and whether key is set. The key
+// is normalized as in Get.
+func GetLast(cfg map[string][]string, key string, opts ...LookupOption) (string, bool) {
+	values, _ := find(cfg, key, opts)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// GetRegexp returns the entries of cfg whose key matches pattern, like
+// `git config --get-regexp`. pattern is a Go regular expression matched
+// against the full key as stored by Parse ("remote.origin.url"), so it is
+// unanchored unless it uses ^ and $. An invalid pattern returns the error
+// from regexp.Compile.
+func GetRegexp(cfg map[string]string, pattern string) (map[string]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches := map[string]string{}
+	for key, value := range cfg {
+		if re.MatchString(key) {
+			matches[key] = value
+		}
+	}
+	return matches, nil
+}
+
+// GetMatching returns the value of key, normalized as in Get, if it
+// matches valuePattern, like `git config --get key value-pattern`. As in
+// git, a pattern starting with '!' selects the values that do not match
+// the rest of it. The bool result reports whether key is set and its value
+// matches. An invalid pattern returns the error from regexp.Compile.
+func GetMatching(cfg map[string]string, key, valuePattern string) (string, bool, error) {
+	match, err := valueMatcher(valuePattern)
+	if err != nil {
+		return "", false, err
+	}
+	value, ok := find(cfg, key, nil)
+	if !ok || !match(value) {
+		return "", false, nil
+	}
+	return value, true, nil
+}
+
+// GetAllMatching returns the values of key in cfg, a map as returned by
+// ParseMulti, that match valuePattern as in GetMatching, in file order. It
+// returns nil if no value matches.
+func GetAllMatching(cfg map[string][]string, key, valuePattern string) ([]string, error) {
+	match, err := valueMatcher(valuePattern)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, value := range GetAll(cfg, key) {
+		if match(value) {
+			matches = append(matches, value)
+		}
+	}
+	return matches, nil
+}
+
+// valueMatcher compiles a value pattern, which may be negated with '!'.
+func valueMatcher(pattern string) (func(string) bool, error) {
+	negate := strings.HasPrefix(pattern, "!")
+	if negate {
+		pattern = pattern[1:]
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return func(value string) bool {
+		return re.MatchString(value) != negate
+	}, nil
+}
+
+func find[V any](cfg map[string]V, key string, opts []LookupOption) (V, bool) {
+	key = normalizeKey(key)
+	if value, ok := cfg[key]; ok {
+		return value, true
+	}
+	if !newLookupOptions(opts).foldSubsection {
+		var zero V
+		return zero, false
+	}
+	match, found := "", false
+	for candidate := range cfg {
+		if strings.EqualFold(candidate, key) && (!found || candidate < match) {
+			match, found = candidate, true
+		}
+	}
+	return cfg[match], found
+}
+
+// NormalizeKey returns the canonical form of key, the form Parse stores
+// keys in, following git's rules. The key is split at its first and its
+// last dot: the part before the first dot is the section, the part after
+// the last dot the variable name, and everything in between, dots
+// included, the subsection. So "URL.git@example.com:foo.bar.insteadOf"
+// has the subsection "git@example.com:foo.bar". The section and the
+// variable name are lowercased, the subsection is kept as is.
+//
+// The section must be made of letters, digits and hyphens, otherwise
+// NormalizeKey returns ErrInvalidSectionChar; the variable name in addition
+// must start with a letter, otherwise it returns ErrInvalidKeyChar, as it
+// does for a key without a dot. A subsection may contain anything but a
+// newline or carriage return (ErrSectionNewLine). Get and Has apply the
+// same normalization without the validation, so they simply find nothing
+// for invalid keys.
+func NormalizeKey(key string) (string, error) {
+	key = normalizeKey(key)
+	if _, _, _, err := splitFlatKey(key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// normalizeKey lowercases the section and variable name of key.
+func normalizeKey(key string) string {
+	first := strings.IndexByte(key, '.')
+	last := strings.LastIndexByte(key, '.')
+	if first < 0 {
+		return strings.ToLower(key)
+	}
+	return strings.ToLower(key[:first]) + key[first:last+1] + strings.ToLower(key[last+1:])
+}
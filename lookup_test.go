@@ -0,0 +1,226 @@
+package goconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	config, _, err := Parse([]byte(`[user]
+	name = Danyel
+[remote "Origin"]
+	url = https://example.com/repo.git
+[http "https://my-website.com"]
+	sslVerify = false`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, "Danyel", Get(config, "user.name", "default"))
+	assert.Equal(t, "Danyel", Get(config, "User.Name", "default"))
+	assert.Equal(t, "https://example.com/repo.git", Get(config, "REMOTE.Origin.URL", "default"))
+	assert.Equal(t, "default", Get(config, "remote.origin.url", "default"))
+	assert.Equal(t, "false", Get(config, "Http.https://my-website.com.SSLVerify", "default"))
+	assert.Equal(t, "default", Get(config, "user.email", "default"))
+}
+
+func TestNormalizeKeyParts(t *testing.T) {
+	tests := map[string]string{
+		"User.Name":                  "user.name",
+		"Remote.Origin.URL":          "remote.Origin.url",
+		"URL.https://X.Y/.InsteadOf": "url.https://X.Y/.insteadof",
+		"NoDot":                      "nodot",
+	}
+	for key, expected := range tests {
+		assert.Equal(t, expected, normalizeKey(key), key)
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	for key, expected := range map[string]string{
+		"User.Email":                            "user.email",
+		"Remote.Origin.URL":                     "remote.Origin.url",
+		"URL.git@example.com:foo.bar.insteadOf": "url.git@example.com:foo.bar.insteadof",
+		"http.https://Example.COM/.sslVerify":   "http.https://Example.COM/.sslverify",
+		"core-x.my-key2":                        "core-x.my-key2",
+		"a..b":                                  "a..b",
+	} {
+		normalized, err := NormalizeKey(key)
+		assert.Equal(t, nil, err, key)
+		assert.Equal(t, expected, normalized, key)
+	}
+	for key, expected := range map[string]error{
+		"nodot":           ErrInvalidKeyChar,
+		".name":           ErrInvalidSectionChar,
+		"us_er.name":      ErrInvalidSectionChar,
+		"user.":           ErrInvalidKeyChar,
+		"user.1name":      ErrInvalidKeyChar,
+		"user.na_me":      ErrInvalidKeyChar,
+		"remote.a\nb.url": ErrSectionNewLine,
+		"remote.a\rb.url": ErrSectionNewLine,
+	} {
+		_, err := NormalizeKey(key)
+		assert.Equal(t, expected, err, key)
+	}
+}
+
+func TestHas(t *testing.T) {
+	config, _, err := Parse([]byte("[core]\n\tbare\n\tempty =\n[remote \"Origin\"]\n\turl = x\n"))
+	assert.Equal(t, nil, err)
+	assert.True(t, Has(config, "core.bare"))
+	assert.True(t, Has(config, "Core.Empty"))
+	assert.True(t, Has(config, "remote.Origin.URL"))
+	assert.False(t, Has(config, "remote.origin.url"))
+	assert.False(t, Has(config, "core.missing"))
+	assert.False(t, Has(nil, "core.bare"))
+	assert.Equal(t, "", Get(config, "core.empty", "default"))
+}
+
+func TestGetFirst(t *testing.T) {
+	config := map[string]string{"core.legacyname": "master", "core.empty": ""}
+	value, ok := GetFirst(config, "init.defaultBranch", "Core.LegacyName")
+	assert.True(t, ok)
+	assert.Equal(t, "master", value)
+
+	config["init.defaultbranch"] = "main"
+	value, ok = GetFirst(config, "init.defaultBranch", "core.legacyName")
+	assert.True(t, ok)
+	assert.Equal(t, "main", value)
+
+	value, ok = GetFirst(config, "core.empty", "init.defaultbranch")
+	assert.True(t, ok)
+	assert.Equal(t, "", value)
+
+	_, ok = GetFirst(config, "a.b", "c.d")
+	assert.False(t, ok)
+	_, ok = GetFirst(config)
+	assert.False(t, ok)
+}
+
+func TestEquals(t *testing.T) {
+	config, _, err := Parse([]byte("[feature]\n\tmanyFiles = True\n\tempty =\n[remote \"Origin\"]\n\turl = x\n"))
+	assert.Equal(t, nil, err)
+	assert.True(t, Equals(config, "Feature.ManyFiles", "True"))
+	assert.False(t, Equals(config, "feature.manyfiles", "true"))
+	assert.True(t, Equals(config, "feature.manyfiles", "true", FoldValue()))
+	assert.True(t, Equals(config, "feature.empty", ""))
+	assert.False(t, Equals(config, "feature.missing", ""))
+	assert.False(t, Equals(config, "remote.origin.url", "x"))
+	assert.True(t, Equals(config, "remote.origin.url", "X", FoldSubsection(), FoldValue()))
+	assert.False(t, Equals(nil, "feature.empty", ""))
+}
+
+func TestFoldSubsection(t *testing.T) {
+	config := map[string]string{
+		"remote.origin.url":   "o",
+		"remote.Upstream.url": "U",
+		"remote.upstream.url": "u",
+	}
+	assert.Equal(t, "default", Get(config, "remote.Origin.url", "default"))
+	assert.False(t, Has(config, "remote.ORIGIN.url"))
+
+	assert.Equal(t, "o", Get(config, "remote.Origin.url", "default", FoldSubsection()))
+	assert.True(t, Has(config, "Remote.ORIGIN.URL", FoldSubsection()))
+	assert.Equal(t, "u", Get(config, "remote.upstream.url", "default", FoldSubsection()))
+	assert.Equal(t, "U", Get(config, "remote.UPSTREAM.url", "default", FoldSubsection()))
+	assert.Equal(t, "default", Get(config, "remote.other.url", "default", FoldSubsection()))
+}
+
+func TestGetAll(t *testing.T) {
+	config, _, err := ParseMulti([]byte(`[remote "origin"]
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	url = https://example.com/repo.git
+	fetch = +refs/tags/*:refs/tags/*`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, []string{"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"}, GetAll(config, "Remote.origin.FETCH"))
+	assert.Equal(t, []string{"https://example.com/repo.git"}, GetAll(config, "remote.origin.url"))
+	assert.Nil(t, GetAll(config, "remote.Origin.fetch"))
+	assert.Len(t, GetAll(config, "remote.Origin.fetch", FoldSubsection()), 2)
+	assert.Nil(t, GetAll(nil, "remote.origin.url"))
+
+	last, ok := GetLast(config, "remote.origin.fetch")
+	assert.True(t, ok)
+	assert.Equal(t, "+refs/tags/*:refs/tags/*", last)
+	_, ok = GetLast(config, "remote.origin.pushurl")
+	assert.False(t, ok)
+	_, ok = GetLast(map[string][]string{"a.b": {}}, "a.b")
+	assert.False(t, ok)
+}
+
+func TestGetRegexp(t *testing.T) {
+	config := map[string]string{
+		"alias.co":                      "checkout",
+		"alias.st":                      "status",
+		"url.git@github.com:.insteadof": "https://github.com/",
+		"url.git@gitlab.com:.insteadof": "https://gitlab.com/",
+		"url.git@example.com:.pushurl":  "x",
+		"core.editor":                   "vim",
+		"remote.alias.url":              "https://example.com/alias.git",
+	}
+	matches, err := GetRegexp(config, `^alias\.`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{"alias.co": "checkout", "alias.st": "status"}, matches)
+
+	matches, err = GetRegexp(config, `^url\..*\.insteadof$`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{
+		"url.git@github.com:.insteadof": "https://github.com/",
+		"url.git@gitlab.com:.insteadof": "https://gitlab.com/",
+	}, matches)
+
+	matches, err = GetRegexp(config, "alias")
+	assert.Equal(t, nil, err)
+	assert.Len(t, matches, 3)
+
+	matches, err = GetRegexp(config, "nomatch")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{}, matches)
+
+	_, err = GetRegexp(config, "(")
+	assert.Error(t, err)
+}
+
+func TestGetMatching(t *testing.T) {
+	config := map[string]string{"core.editor": "vim", "core.pager": "less -R"}
+	value, ok, err := GetMatching(config, "Core.Editor", "^vi")
+	assert.Equal(t, nil, err)
+	assert.True(t, ok)
+	assert.Equal(t, "vim", value)
+
+	_, ok, err = GetMatching(config, "core.editor", "emacs")
+	assert.Equal(t, nil, err)
+	assert.False(t, ok)
+	_, ok, err = GetMatching(config, "core.missing", ".*")
+	assert.Equal(t, nil, err)
+	assert.False(t, ok)
+
+	value, ok, err = GetMatching(config, "core.pager", "!more")
+	assert.Equal(t, nil, err)
+	assert.True(t, ok)
+	assert.Equal(t, "less -R", value)
+	_, ok, _ = GetMatching(config, "core.pager", "!less")
+	assert.False(t, ok)
+
+	_, _, err = GetMatching(config, "core.editor", "[")
+	assert.Error(t, err)
+}
+
+func TestGetAllMatching(t *testing.T) {
+	config := map[string][]string{
+		"remote.origin.fetch": {"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*", "+refs/notes/*:refs/notes/*"},
+	}
+	values, err := GetAllMatching(config, "remote.origin.fetch", "heads|tags")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"}, values)
+
+	values, err = GetAllMatching(config, "remote.origin.fetch", "!heads|tags")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"+refs/notes/*:refs/notes/*"}, values)
+
+	values, err = GetAllMatching(config, "remote.origin.fetch", "pull")
+	assert.Equal(t, nil, err)
+	assert.Nil(t, values)
+
+	_, err = GetAllMatching(config, "remote.origin.fetch", "(")
+	assert.Error(t, err)
+}
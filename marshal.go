@@ -0,0 +1,281 @@
+package goconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// group holds the variables of one section/subsection pair.
+type group struct {
+	section    string
+	subsection string
+	// quoted is set if the header has a subsection, which may be empty
+	// as in [section ""].
+	quoted bool
+	keys   []string
+	values []string
+}
+
+// Marshal serializes cfg, a map as returned by Parse, to gitconfig syntax.
+// Keys are grouped under one header per section and subsection. Values are
+// quoted and escaped where needed, so that Parse(Marshal(cfg)) returns cfg.
+// Control characters are written as they are, like git does, since git
+// knows no escape for them. A value with a carriage return, which Parse
+// would read as a line ending, returns ErrCarriageReturn.
+//
+// The output is deterministic: sections are sorted by name, the headers of
+// one section by subsection (the header without subsection first), and the
+// keys below a header by name. All comparisons are bytewise.
+func Marshal(cfg map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := WriteTo(&buf, cfg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo writes cfg to w in the same format and order as Marshal, one
+// section at a time, and returns the number of bytes written.
+func WriteTo(w io.Writer, cfg map[string]string) (int64, error) {
+	return writeMulti(w, toMulti(cfg))
+}
+
+// WriteFile writes cfg to the file at path in the format of Marshal. Like
+// git, it writes to path + ".lock" first and renames that over path, so
+// the file is never left half written; if the lock file already exists,
+// another writer is at work and WriteFile fails with an error wrapping
+// fs.ErrExist. A new file is created with permissions perm (before the
+// umask), an existing file keeps its mode. If path is a symbolic link,
+// the file it points to is written and the link is kept, as in git.
+// Errors from the file system are wrapped like those of ParseFile.
+func WriteFile(path string, cfg map[string]string, perm os.FileMode) error {
+	b, err := Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("goconfig: %w", err)
+	}
+	info, statErr := os.Stat(path)
+	if statErr == nil {
+		perm = info.Mode().Perm()
+	}
+	lock := path + ".lock"
+	f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return fmt.Errorf("goconfig: %w", err)
+	}
+	_, err = f.Write(b)
+	if err == nil && statErr == nil {
+		// The umask applies to new files only.
+		err = f.Chmod(perm)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(lock, path)
+	}
+	if err != nil {
+		os.Remove(lock)
+		return fmt.Errorf("goconfig: %w", err)
+	}
+	return nil
+}
+
+// writeMulti writes every value of each key of cfg, in order.
+func writeMulti(w io.Writer, cfg map[string][]string) (int64, error) {
+	groups, err := groupKeys(cfg)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	var buf bytes.Buffer
+	for _, g := range groups {
+		buf.Reset()
+		writeGroup(&buf, g)
+		n, err := w.Write(buf.Bytes())
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func groupKeys(cfg map[string][]string) ([]*group, error) {
+	index := map[string]*group{}
+	var groups []*group
+	for _, key := range sortedKeys(cfg) {
+		section, subsection, name, err := splitFlatKey(key)
+		if err != nil {
+			return nil, err
+		}
+		id := key[:len(key)-len(name)]
+		g, ok := index[id]
+		if !ok {
+			quoted := strings.IndexByte(key, '.') != strings.LastIndexByte(key, '.')
+			g = &group{section: section, subsection: subsection, quoted: quoted}
+			index[id] = g
+			groups = append(groups, g)
+		}
+		for _, value := range cfg[key] {
+			if strings.ContainsRune(value, '\r') {
+				return nil, ErrCarriageReturn
+			}
+			g.keys = append(g.keys, name)
+			g.values = append(g.values, value)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].section != groups[j].section {
+			return groups[i].section < groups[j].section
+		}
+		if groups[i].subsection != groups[j].subsection {
+			return groups[i].subsection < groups[j].subsection
+		}
+		return !groups[i].quoted && groups[j].quoted
+	})
+	return groups, nil
+}
+
+// splitFlatKey splits a flat key into section, subsection and variable
+// name and validates them. A subsection must not contain a '\n' or '\r',
+// which Parse reads as the end of the header line.
+func splitFlatKey(key string) (section, subsection, name string, err error) {
+	if !strings.Contains(key, ".") {
+		return "", "", "", ErrInvalidKeyChar
+	}
+	section, subsection, name = SplitKey(key)
+	if !validSection(section) {
+		return "", "", "", ErrInvalidSectionChar
+	}
+	if !validKey(name) {
+		return "", "", "", ErrInvalidKeyChar
+	}
+	if strings.ContainsAny(subsection, "\r\n") {
+		return "", "", "", ErrSectionNewLine
+	}
+	return section, subsection, name, nil
+}
+
+func validSection(section string) bool {
+	if section == "" {
+		return false
+	}
+	for _, c := range section {
+		if !iskeychar(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func validKey(key string) bool {
+	for i, c := range key {
+		if i == 0 && !isalpha(c) || !iskeychar(c) {
+			return false
+		}
+	}
+	return key != ""
+}
+
+func writeGroup(buf *bytes.Buffer, g *group) {
+	writeHeader(buf, g.section, g.subsection, g.quoted)
+	for i, key := range g.keys {
+		buf.WriteByte('\t')
+		buf.WriteString(key)
+		buf.WriteString(" = ")
+		writeValue(buf, g.values[i])
+		buf.WriteByte('\n')
+	}
+}
+
+// writeHeader writes the header line of a section and, if quoted is set,
+// subsection.
+func writeHeader(buf *bytes.Buffer, section, subsection string, quoted bool) {
+	buf.WriteByte('[')
+	buf.WriteString(section)
+	if quoted {
+		buf.WriteString(` "`)
+		for _, c := range subsection {
+			if c == '"' || c == '\\' {
+				buf.WriteByte('\\')
+			}
+			buf.WriteRune(c)
+		}
+		buf.WriteByte('"')
+	}
+	buf.WriteString("]\n")
+}
+
+// NeedsQuoting reports whether value must be enclosed in double quotes to
+// be read back unchanged, which is the case if it starts or ends with
+// whitespace (which the parser drops outside quotes), contains '#' or ';'
+// (which start a comment outside quotes), or contains whitespace other
+// than space, tab and newline, such as a form feed or U+00A0 (which the
+// parser reads as a space outside quotes). Other special characters are
+// escaped instead, see QuoteValue.
+func NeedsQuoting(value string) bool {
+	first, _ := utf8.DecodeRuneInString(value)
+	last, _ := utf8.DecodeLastRuneInString(value)
+	return value != "" && (isspace(first) || isspace(last) ||
+		strings.ContainsAny(value, "#;") || strings.IndexFunc(value, isOtherSpace) >= 0)
+}
+
+// isOtherSpace reports whether c is whitespace that writeValue neither
+// escapes nor can write as is outside quotes.
+func isOtherSpace(c rune) bool {
+	return isspace(c) && c != ' ' && c != '\t' && c != '\n'
+}
+
+// QuoteValue returns value as Marshal writes it after the '=', like git
+// does: in double quotes if NeedsQuoting reports so, with newline, tab and
+// backspace escaped as \n, \t and \b, and '"' and '\' escaped with a
+// backslash. Anything else, including other control characters and
+// non-ASCII text, is written as is. A carriage return is kept in quotes
+// for git, but Parse reads it as a line ending.
+func QuoteValue(value string) string {
+	var buf bytes.Buffer
+	writeValue(&buf, value)
+	return buf.String()
+}
+
+// writeValue writes value as QuoteValue returns it.
+func writeValue(buf *bytes.Buffer, value string) {
+	quote := NeedsQuoting(value)
+	if quote {
+		buf.WriteByte('"')
+	}
+	for _, c := range value {
+		switch c {
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(c)
+		default:
+			buf.WriteRune(c)
+		}
+	}
+	if quote {
+		buf.WriteByte('"')
+	}
+}
@@ -0,0 +1,290 @@
+package goconfig
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshal(t *testing.T) {
+	cfg := map[string]string{
+		"user.name":                             "Danyel Bayraktar",
+		"user.email":                            "cydrop@gmail.com",
+		"core.editor":                           "subl -w",
+		`remote.my "origin".url`:                `C:\Repos\x`,
+		"http.https://my-website.com.sslverify": "false",
+	}
+	out, err := Marshal(cfg)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `[core]
+	editor = subl -w
+[http "https://my-website.com"]
+	sslverify = false
+[remote "my \"origin\""]
+	url = C:\\Repos\\x
+[user]
+	email = cydrop@gmail.com
+	name = Danyel Bayraktar
+`, string(out))
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	cfg := map[string]string{
+		"core.leading":     "  indented",
+		"core.trailing":    "trailing\t",
+		"core.comment":     "a # b ; c",
+		"core.escapes":     "tab\there\nnewline \"quoted\" back\\slash\bbell",
+		"core.empty":       "",
+		"sub.sec.tion.key": "dotted subsection",
+		"core.control":     "bell\adel\x7fesc\x1b\x00nul",
+		"core.feed":        "vt\vff\f",
+		"core.nbsp":        "\u00a0padded\u00a0",
+		"core.inner":       "x\u00a0y\u0085z\u2028w\u3000v",
+	}
+	out, err := Marshal(cfg)
+	assert.Equal(t, nil, err)
+	parsed, _, err := Parse(out)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, cfg, parsed)
+
+	filename := "configs/danyel.gitconfig"
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Reading file %v failed", filename)
+	}
+	cfg, _, _ = Parse(bytes)
+	out, err = Marshal(cfg)
+	assert.Equal(t, nil, err)
+	parsed, _, err = Parse(out)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, cfg, parsed)
+	// Control characters are written raw, as git knows no escape for them.
+	out, err = Marshal(map[string]string{"a.b": "x\ay"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "[a]\n\tb = x\ay\n", string(out))
+	_, err = Marshal(map[string]string{"a.b": "x\ry"})
+	assert.ErrorIs(t, err, ErrCarriageReturn)
+}
+
+func TestMarshalEmptySubsection(t *testing.T) {
+	config, _, err := Parse([]byte("[a]\n\tc = 1\n[a \"\"]\n\tc = 2\n[a.]\n\td = 3\n"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{"a.c": "1", "a..c": "2", "a..d": "3"}, config)
+
+	out, err := Marshal(config)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "[a]\n\tc = 1\n[a \"\"]\n\tc = 2\n\td = 3\n", string(out))
+	again, _, err := Parse(out)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, config, again)
+}
+
+func TestMarshalInvalidKey(t *testing.T) {
+	tests := map[string]error{
+		"nosection":     ErrInvalidKeyChar,
+		"user.":         ErrInvalidKeyChar,
+		"user.1name":    ErrInvalidKeyChar,
+		"us_er.name":    ErrInvalidSectionChar,
+		".name":         ErrInvalidSectionChar,
+		"a.new\nline.b": ErrSectionNewLine,
+		"a.cr\rline.b":  ErrSectionNewLine,
+	}
+	for key, expected := range tests {
+		_, err := Marshal(map[string]string{key: "value"})
+		assert.Equal(t, expected, err, key)
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	cfg := map[string]string{
+		"user.name":         "Danyel",
+		"remote.origin.url": "https://example.com/repo.git",
+	}
+	var buf bytes.Buffer
+	n, err := WriteTo(&buf, cfg)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	expected, _ := Marshal(cfg)
+	assert.Equal(t, expected, buf.Bytes())
+}
+
+type limitedWriter struct {
+	limit int
+}
+
+var errFull = errors.New("writer full")
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errFull
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestWriteToError(t *testing.T) {
+	cfg := map[string]string{"a.x": "1", "b.y": "2"}
+	n, err := WriteTo(&limitedWriter{limit: 12}, cfg)
+	assert.Equal(t, errFull, err)
+	assert.Equal(t, int64(12), n)
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	cfg := map[string]string{"core.editor": "vi", "user.name": "Danyel"}
+	assert.Equal(t, nil, WriteFile(path, cfg, 0o600))
+	info, err := os.Stat(path)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	config, _, err := ParseFile(path)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, cfg, config)
+	expected, _ := Marshal(cfg)
+	b, _ := ioutil.ReadFile(path)
+	assert.Equal(t, string(expected), string(b))
+
+	// Overwriting keeps the mode of the file.
+	assert.Equal(t, nil, os.Chmod(path, 0o640))
+	cfg["core.editor"] = "vim"
+	assert.Equal(t, nil, WriteFile(path, cfg, 0o600))
+	info, _ = os.Stat(path)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+	config, _, _ = ParseFile(path)
+	assert.Equal(t, "vim", config["core.editor"])
+	_, err = os.Stat(path + ".lock")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	// An invalid key or an existing lock file leave the file alone.
+	assert.True(t, errors.Is(WriteFile(path, map[string]string{"nodot": "x"}, 0o600), ErrInvalidKeyChar))
+	assert.Equal(t, nil, ioutil.WriteFile(path+".lock", nil, 0o600))
+	err = WriteFile(path, map[string]string{"core.editor": "emacs"}, 0o600)
+	assert.True(t, errors.Is(err, os.ErrExist))
+	config, _, _ = ParseFile(path)
+	assert.Equal(t, cfg, config)
+
+	err = WriteFile(filepath.Join(path, "sub"), cfg, 0o600)
+	assert.NotEqual(t, nil, err)
+
+	// A new file is subject to the umask, like one made by os.OpenFile.
+	dir := t.TempDir()
+	f, err := os.OpenFile(filepath.Join(dir, "reference"), os.O_CREATE|os.O_WRONLY, 0o666)
+	assert.Equal(t, nil, err)
+	reference, _ := f.Stat()
+	f.Close()
+	assert.Equal(t, nil, WriteFile(filepath.Join(dir, "config"), cfg, 0o666))
+	info, err = os.Stat(filepath.Join(dir, "config"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, reference.Mode().Perm(), info.Mode().Perm())
+
+	// A symbolic link is followed: the target is written, the link stays.
+	target := filepath.Join(dir, "dotfiles", "gitconfig")
+	assert.Equal(t, nil, os.Mkdir(filepath.Dir(target), 0o700))
+	assert.Equal(t, nil, ioutil.WriteFile(target, []byte("[core]\n\tbare\n"), 0o640))
+	link := filepath.Join(dir, ".gitconfig")
+	assert.Equal(t, nil, os.Symlink(filepath.Join("dotfiles", "gitconfig"), link))
+	assert.Equal(t, nil, WriteFile(link, cfg, 0o600))
+	linkInfo, err := os.Lstat(link)
+	assert.Equal(t, nil, err)
+	assert.True(t, linkInfo.Mode()&os.ModeSymlink != 0)
+	config, _, err = ParseFile(target)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, cfg, config)
+	info, _ = os.Stat(target)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+	_, err = os.Stat(target + ".lock")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	_, err = os.Lstat(link + ".lock")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestMarshalStable(t *testing.T) {
+	cfg := map[string]string{}
+	for _, section := range []string{"user", "core", "remote", "branch", "alias", "a-b"} {
+		for _, subsection := range []string{"", "origin", "Upstream", "feature/x", "a.b"} {
+			for _, key := range []string{"url", "name", "fetch", "email", "b-c"} {
+				if subsection == "" {
+					cfg[section+"."+key] = key
+				} else {
+					cfg[section+"."+subsection+"."+key] = subsection + key
+				}
+			}
+		}
+	}
+	expected, err := Marshal(cfg)
+	assert.Equal(t, nil, err)
+	for i := 0; i < 20; i++ {
+		out, err := Marshal(cfg)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, expected, out)
+	}
+}
+
+func TestMarshalOrder(t *testing.T) {
+	cfg := map[string]string{
+		"remote.upstream.url": "u",
+		"remote.origin.url":   "o",
+		"remote.origin.fetch": "f",
+		"remote.pushdefault":  "origin",
+		"core.bare":           "false",
+		"a-b.c":               "d",
+	}
+	out, err := Marshal(cfg)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `[a-b]
+	c = d
+[core]
+	bare = false
+[remote]
+	pushdefault = origin
+[remote "origin"]
+	fetch = f
+	url = o
+[remote "upstream"]
+	url = u
+`, string(out))
+}
+
+func TestQuoteValue(t *testing.T) {
+	tests := []struct {
+		value  string
+		quoted bool
+		out    string
+	}{
+		{"", false, ""},
+		{"plain value", false, "plain value"},
+		{" leading", true, `" leading"`},
+		{"trailing\t", true, `"trailing\t"`},
+		{"a#b", true, `"a#b"`},
+		{"a;b", true, `"a;b"`},
+		{`say "hi"`, false, `say \"hi\"`},
+		{`C:\dir`, false, `C:\\dir`},
+		{"two\nlines", false, `two\nlines`},
+		{"\bbell\x07", false, "\\bbell\x07"},
+		{"\x7f", false, "\x7f"},
+		{"v\vtab", true, "\"v\vtab\""},
+		{"form\ffeed", true, "\"form\ffeed\""},
+		{"x\u00a0y", true, "\"x\u00a0y\""},
+		{"x\u0085y", true, "\"x\u0085y\""},
+		{"line\u2028sep", true, "\"line\u2028sep\""},
+		{"a\tb c\nd", false, `a\tb c\nd`},
+		{"naïve", false, "naïve"},
+		{"\n", true, `"\n"`},
+		{" # ", true, `" # "`},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.quoted, NeedsQuoting(test.value), test.value)
+		out := QuoteValue(test.value)
+		assert.Equal(t, test.out, out, test.value)
+
+		config, _, err := Parse([]byte("[a]\n\tk = " + out + "\n"))
+		assert.Equal(t, nil, err, test.value)
+		assert.Equal(t, test.value, config["a.k"], test.value)
+	}
+}
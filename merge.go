@@ -0,0 +1,62 @@
+package goconfig
+
+// Merge overlays the given configurations from left to right: a key set in
+// a later map overrides the same key in earlier ones, like the local config
+// overrides the global and system config in git. The inputs are not
+// modified.
+func Merge(configs ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, cfg := range configs {
+		for key, value := range cfg {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// MergeMulti merges configurations as returned by ParseMulti by
+// concatenating the values of each key in order, like git does for
+// multi-valued keys. The inputs are not modified.
+func MergeMulti(configs ...map[string][]string) map[string][]string {
+	merged := map[string][]string{}
+	for _, cfg := range configs {
+		for key, values := range cfg {
+			merged[key] = append(merged[key], values...)
+		}
+	}
+	return merged
+}
+
+// Clone returns a copy of cfg that can be changed, e.g. with Set or Unset,
+// without affecting cfg.
+func Clone(cfg map[string]string) map[string]string {
+	clone := make(map[string]string, len(cfg))
+	for key, value := range cfg {
+		clone[key] = value
+	}
+	return clone
+}
+
+// CloneMulti returns a deep copy of cfg, a map as returned by ParseMulti:
+// the value slices are copied as well, so appending to or changing a value
+// of the clone does not affect cfg.
+func CloneMulti(cfg map[string][]string) map[string][]string {
+	clone := make(map[string][]string, len(cfg))
+	for key, values := range cfg {
+		clone[key] = append([]string(nil), values...)
+	}
+	return clone
+}
+
+// WithDefaults returns a copy of cfg with the keys of defaults that cfg
+// does not set, e.g. a baseline remote.origin.tagopt. Unlike Merge, a key
+// set in cfg always wins, whatever its value. The inputs are not modified.
+func WithDefaults(cfg, defaults map[string]string) map[string]string {
+	merged := Clone(cfg)
+	for key, value := range defaults {
+		if _, ok := merged[key]; !ok {
+			merged[key] = value
+		}
+	}
+	return merged
+}
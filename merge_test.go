@@ -0,0 +1,93 @@
+package goconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	system := map[string]string{"core.editor": "vi", "core.pager": "less", "user.name": "System"}
+	global := map[string]string{"core.editor": "emacs", "user.name": "Global"}
+	local := map[string]string{"core.editor": "subl -w", "user.email": "local@example.com"}
+
+	merged := Merge(system, global, local)
+	assert.Equal(t, map[string]string{
+		"core.editor": "subl -w",
+		"core.pager":  "less",
+		"user.name":   "Global",
+		"user.email":  "local@example.com",
+	}, merged)
+
+	assert.Equal(t, map[string]string{"core.editor": "vi", "core.pager": "less", "user.name": "System"}, system)
+	assert.Equal(t, map[string]string{"core.editor": "emacs", "user.name": "Global"}, global)
+	assert.Equal(t, map[string]string{"core.editor": "subl -w", "user.email": "local@example.com"}, local)
+	assert.Equal(t, map[string]string{}, Merge())
+}
+
+func TestMergeMulti(t *testing.T) {
+	system := map[string][]string{"remote.origin.fetch": {"a"}}
+	global := map[string][]string{"remote.origin.fetch": {"b", "c"}, "include.path": {"x"}}
+	local := map[string][]string{"remote.origin.fetch": {"d"}}
+
+	merged := MergeMulti(system, global, local)
+	assert.Equal(t, map[string][]string{
+		"remote.origin.fetch": {"a", "b", "c", "d"},
+		"include.path":        {"x"},
+	}, merged)
+
+	merged["include.path"][0] = "changed"
+	merged["remote.origin.fetch"][0] = "changed"
+	assert.Equal(t, map[string][]string{"remote.origin.fetch": {"a"}}, system)
+	assert.Equal(t, map[string][]string{"remote.origin.fetch": {"b", "c"}, "include.path": {"x"}}, global)
+}
+
+func TestClone(t *testing.T) {
+	config := map[string]string{"core.editor": "vi", "user.name": "Danyel"}
+	clone := Clone(config)
+	assert.Equal(t, config, clone)
+
+	assert.Equal(t, nil, Set(clone, "core.editor", "vim"))
+	assert.True(t, Unset(clone, "user.name"))
+	assert.Equal(t, nil, Set(clone, "user.email", "cydrop@gmail.com"))
+	assert.Equal(t, map[string]string{"core.editor": "vi", "user.name": "Danyel"}, config)
+	assert.Equal(t, map[string]string{"core.editor": "vim", "user.email": "cydrop@gmail.com"}, clone)
+
+	assert.Equal(t, map[string]string{}, Clone(nil))
+}
+
+func TestCloneMulti(t *testing.T) {
+	fetch := make([]string, 2, 4)
+	copy(fetch, []string{"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"})
+	config := map[string][]string{"remote.origin.fetch": fetch}
+	clone := CloneMulti(config)
+	assert.Equal(t, config, clone)
+
+	clone["remote.origin.fetch"][0] = "changed"
+	clone["remote.origin.fetch"] = append(clone["remote.origin.fetch"], "appended")
+	clone["remote.origin.url"] = []string{"https://example.com/repo.git"}
+	assert.Equal(t, map[string][]string{
+		"remote.origin.fetch": {"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"},
+	}, config)
+	assert.Equal(t, "+refs/heads/*:refs/remotes/origin/*", fetch[:3][0])
+	assert.Equal(t, "", fetch[:3][2])
+
+	assert.Equal(t, map[string][]string{}, CloneMulti(nil))
+}
+
+func TestWithDefaults(t *testing.T) {
+	config := map[string]string{"remote.origin.url": "git@example.com:x", "remote.origin.tagopt": ""}
+	defaults := map[string]string{"remote.origin.tagopt": "--no-tags", "remote.origin.prune": "true"}
+	merged := WithDefaults(config, defaults)
+	assert.Equal(t, map[string]string{
+		"remote.origin.url":    "git@example.com:x",
+		"remote.origin.tagopt": "",
+		"remote.origin.prune":  "true",
+	}, merged)
+	assert.Equal(t, map[string]string{"remote.origin.url": "git@example.com:x", "remote.origin.tagopt": ""}, config)
+	assert.Equal(t, map[string]string{"remote.origin.tagopt": "--no-tags", "remote.origin.prune": "true"}, defaults)
+
+	merged["core.editor"] = "vi"
+	assert.NotContains(t, config, "core.editor")
+	assert.Equal(t, defaults, WithDefaults(nil, defaults))
+}
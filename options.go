@@ -0,0 +1,302 @@
+package goconfig
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Option changes how the parser behaves. Options are passed to Parse and
+// the other parse functions; without options the parser follows git.
+type Option func(*options)
+
+type options struct {
+	bareTrue        bool
+	maxIncludeDepth int
+	includeContext  IncludeContext
+	maxBytes        int64
+	maxLines        uint
+
+	rejectDuplicates  bool
+	rejectLegacy      bool
+	keepCase          bool
+	lenientEscapes    bool
+	hexEscapes        bool
+	noInlineComments  bool
+	slashComments     bool
+	noHashComments    bool
+	noSemiComments    bool
+	rawValues         bool
+	sections          map[string]bool
+	rejectInvalidUTF8 bool
+	checkSpacing      func(SpacingWarning)
+	onComplete        func(ParseStats)
+}
+
+// Default input limits. They are far above the size of any real config
+// file and only guard against hostile input.
+const (
+	DefaultMaxBytes = 64 << 20
+	DefaultMaxLines = 1 << 20
+)
+
+func newOptions(opts []Option) options {
+	o := options{
+		maxIncludeDepth: 10,
+		maxBytes:        DefaultMaxBytes,
+		maxLines:        DefaultMaxLines,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// BareKeysAsTrue stores "true" for a key that has no '=' at all, which git
+// reads as the boolean true:
+//
+//	[core]
+//		bare
+//
+// yields core.bare = "true". A key with '=' but no value ("key =") is still
+// stored as the empty string, so the two remain distinguishable.
+func BareKeysAsTrue() Option {
+	return func(o *options) {
+		o.bareTrue = true
+	}
+}
+
+// MaxIncludeDepth sets how deeply include.path directives may nest before
+// ParseWithIncludes fails with ErrIncludeDepth. The default is 10, like git.
+func MaxIncludeDepth(depth int) Option {
+	return func(o *options) {
+		o.maxIncludeDepth = depth
+	}
+}
+
+// IncludeContext describes the repository that includeIf conditions are
+// evaluated against.
+type IncludeContext struct {
+	// GitDir is the path of the .git directory, matched by gitdir: and
+	// gitdir/i: conditions.
+	GitDir string
+	// Branch is the name of the checked out branch without refs/heads/,
+	// matched by onbranch: conditions.
+	Branch string
+}
+
+// WithIncludeContext sets the repository ParseWithIncludes evaluates
+// includeIf conditions against. Without it no gitdir: or onbranch:
+// condition matches.
+func WithIncludeContext(ctx IncludeContext) Option {
+	return func(o *options) {
+		o.includeContext = ctx
+	}
+}
+
+// MaxBytes limits the size of the input. Parsing stops with a ParseError
+// wrapping ErrLimitExceeded once more than n bytes have been read. n <= 0
+// removes the limit. The default is DefaultMaxBytes (64 MiB).
+func MaxBytes(n int64) Option {
+	return func(o *options) {
+		o.maxBytes = n
+	}
+}
+
+// MaxLines limits the number of lines of the input. Parsing stops with a
+// ParseError wrapping ErrLimitExceeded when line n+1 is reached. 0 removes
+// the limit. The default is DefaultMaxLines (1048576 lines).
+func MaxLines(n uint) Option {
+	return func(o *options) {
+		o.maxLines = n
+	}
+}
+
+// RejectDuplicateSections makes the parser fail with ErrDuplicateSection,
+// reported at the second header, when a section (with the same subsection)
+// is opened more than once. By default git's behavior applies and the keys
+// of all headers are merged.
+func RejectDuplicateSections() Option {
+	return func(o *options) {
+		o.rejectDuplicates = true
+	}
+}
+
+// RejectLegacySections makes the parser fail with ErrLegacySectionSyntax,
+// reported at the dot, on the deprecated [section.subsection] header form.
+// By default the legacy form is accepted like git does: the subsection is
+// lowercased and the keys land in the same flat key as the quoted form, so
+// [remote.Origin] and [remote "origin"] both set remote.origin.* and the
+// definition that comes later in the file wins.
+func RejectLegacySections() Option {
+	return func(o *options) {
+		o.rejectLegacy = true
+	}
+}
+
+// KeepCase keeps section and variable names as written instead of
+// lowercasing them, so "[Core] fileMode" yields Core.fileMode rather than
+// core.filemode; a legacy [Section.Sub] header keeps the case of its
+// subsection too. This is not git's behavior. Note that Get, Has and the
+// typed getters lowercase the key they look up, so they find mixed-case
+// keys only with FoldSubsection (Get and Has) or not at all; index the map
+// directly instead.
+func KeepCase() Option {
+	return func(o *options) {
+		o.keepCase = true
+	}
+}
+
+// LenientEscapes keeps an unknown escape sequence such as \q in a value as
+// written, backslash included, instead of failing with
+// ErrInvalidEscapeSequence. With HexEscapes, a \x that is not followed by
+// two hexadecimal digits is still an error.
+func LenientEscapes() Option {
+	return func(o *options) {
+		o.lenientEscapes = true
+	}
+}
+
+// HexEscapes accepts the escape sequence \xNN in values, where NN are two
+// hexadecimal digits giving the code point U+00NN, which is stored UTF-8
+// encoded, e.g. "caf\xe9" for "café". git does not accept \xNN, so files
+// that use it can only be read with this option, and Marshal never writes
+// it. Invalid digits are an ErrInvalidEscapeSequence.
+func HexEscapes() Option {
+	return func(o *options) {
+		o.hexEscapes = true
+	}
+}
+
+// NoInlineComments treats '#' and ';' in a value as literal characters, so
+// "key = a#b ; c" yields "a#b ; c" instead of git's "a", which cuts the
+// value at the first unquoted '#' or ';'. Lines that start with '#' or ';'
+// are still comments, as is the rest of a section header line.
+func NoInlineComments() Option {
+	return func(o *options) {
+		o.noInlineComments = true
+	}
+}
+
+// SlashComments makes "//" start a comment wherever '#' and ';' do: at the
+// start of a line, after a section header and after an unquoted part of
+// a value. This is not git's behavior, and it cuts values such as
+// "url = https://example.com" short unless they are quoted.
+func SlashComments() Option {
+	return func(o *options) {
+		o.slashComments = true
+	}
+}
+
+// DisableHashComments makes '#' an ordinary character, for dialects that
+// only use ';' for comments: a '#' in a value is part of it, and a line
+// that starts with '#' is an error like any other invalid key.
+func DisableHashComments() Option {
+	return func(o *options) {
+		o.noHashComments = true
+	}
+}
+
+// DisableSemicolonComments is like DisableHashComments for ';'.
+func DisableSemicolonComments() Option {
+	return func(o *options) {
+		o.noSemiComments = true
+	}
+}
+
+// WithSections makes the parser store only the variables of the given
+// sections, e.g. WithSections("remote", "branch") for every [remote "..."]
+// and [branch "..."]. Section names are compared case-insensitively. Other
+// sections are still read, so line numbers and errors in headers stay
+// right, but their variables are skipped without being decoded: invalid
+// names or escape sequences in them are not reported. Several WithSections
+// options add up.
+func WithSections(names ...string) Option {
+	return func(o *options) {
+		if o.sections == nil {
+			o.sections = map[string]bool{}
+		}
+		for _, name := range names {
+			o.sections[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// RawValues stores values as written instead of decoding them: quotes and
+// escape sequences are kept, and continuation lines are kept with their
+// backslash, a "\n" line ending and the indentation of the next line. The
+// value still ends where it would end otherwise, at the end of the line
+// or at a '#' or ';' outside quotes, and leading and trailing whitespace
+// outside quotes is still dropped, so
+//
+//	path = "C:\\dir" \t # comment
+//
+// yields `"C:\\dir" \t`. Escape sequences are not validated.
+func RawValues() Option {
+	return func(o *options) {
+		o.rawValues = true
+	}
+}
+
+// RejectInvalidUTF8 makes the parser fail with a ParseError wrapping
+// ErrInvalidUTF8 at the first byte that is not part of a valid UTF-8
+// sequence; its Detail gives the byte offset in the input. By
+// default such bytes are read as U+FFFD, the Unicode replacement character,
+// and stored that way in names and values.
+func RejectInvalidUTF8() Option {
+	return func(o *options) {
+		o.rejectInvalidUTF8 = true
+	}
+}
+
+// SpacingWarning reports a variable that is not written in the canonical
+// "key = value" style, with exactly one space on each side of '='.
+type SpacingWarning struct {
+	// Line and Column are the position of the '='.
+	Line   uint
+	Column uint
+	// Got is the '=' with the whitespace around it as written, Want the
+	// canonical spacing: " = ", or " =" if nothing follows on the line.
+	Got  string
+	Want string
+}
+
+func (w SpacingWarning) String() string {
+	return fmt.Sprintf("line %d, column %d: spacing %q, want %q", w.Line, w.Column, w.Got, w.Want)
+}
+
+// CheckSpacing calls fn for every variable whose spacing around '=' is not
+// canonical, e.g. "key=value" or "key =\tvalue". The warnings do not
+// affect the parse result. Bare keys without '=' are not checked.
+func CheckSpacing(fn func(SpacingWarning)) Option {
+	return func(o *options) {
+		o.checkSpacing = fn
+	}
+}
+
+// ParseStats describes one parse, as reported to OnComplete.
+type ParseStats struct {
+	// Lines is the number of lines read, as returned by Parse.
+	Lines uint
+	// Keys is the number of variables read, counting every assignment of a
+	// key that is set more than once.
+	Keys int
+	// Bytes is the number of input bytes read, including a byte order mark.
+	Bytes int64
+	// Duration is the time the parse took, including the time spent
+	// reading from the io.Reader of ParseReader.
+	Duration time.Duration
+	// Err is the error the parse returned, if any.
+	Err error
+}
+
+// OnComplete calls fn with the statistics of every parse that uses the
+// option, successful or not, e.g. to export them as metrics. It is called
+// once per input, after parsing and before the Parse function returns; with
+// ParseWithIncludes that is once per file. Without the option no
+// statistics are collected.
+func OnComplete(fn func(ParseStats)) Option {
+	return func(o *options) {
+		o.onComplete = fn
+	}
+}
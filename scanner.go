@@ -0,0 +1,161 @@
+package goconfig
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// TokenType identifies the kind of a token returned by Scanner.Scan.
+type TokenType int
+
+const (
+	// TokenEOF is returned once the input is consumed, and by every call
+	// to Scan after that.
+	TokenEOF TokenType = iota
+	// TokenError is returned if the input is invalid, and by every call to
+	// Scan after that; Err returns the error.
+	TokenError
+	// TokenSection is a section header. Its text is the section name as
+	// stored by Parse, e.g. "remote.origin" for [remote "origin"].
+	TokenSection
+	// TokenKey is a variable name, lowercased as in Parse.
+	TokenKey
+	// TokenValue is the value of the key before it, with quotes removed and
+	// escapes and continuation lines resolved. A key without '=' has none,
+	// unless BareKeysAsTrue gives it the value "true".
+	TokenValue
+	// TokenComment is a comment, from its '#' or ';' to the end of the
+	// line, excluding the line ending. It may be a line of its own or
+	// follow a header or a value.
+	TokenComment
+)
+
+var tokenNames = [...]string{
+	TokenEOF:     "EOF",
+	TokenError:   "Error",
+	TokenSection: "Section",
+	TokenKey:     "Key",
+	TokenValue:   "Value",
+	TokenComment: "Comment",
+}
+
+func (t TokenType) String() string {
+	if t < 0 || int(t) >= len(tokenNames) {
+		return "TokenType(" + strconv.Itoa(int(t)) + ")"
+	}
+	return tokenNames[t]
+}
+
+// Scanner splits a configuration into tokens, for callers that build their
+// own data structures or highlight syntax. It reads the input like Parse
+// and with the same options, one token at a time, so it stops at the same
+// errors, e.g. a duplicate section with RejectDuplicateSections. With
+// WithSections, the headers and variables of other sections are skipped;
+// comment lines are returned in every section. OnComplete is called when
+// Scan returns TokenEOF or TokenError for the first time.
+type Scanner struct {
+	cf  *parser
+	err error
+	// start, keys and completed are kept for OnComplete.
+	start     time.Time
+	keys      int
+	completed bool
+	// pending are the value and comment of the last key, returned by the
+	// following calls to Scan.
+	pending []scanToken
+}
+
+type scanToken struct {
+	typ  TokenType
+	text string
+	line uint
+}
+
+// NewScanner returns a Scanner reading b.
+func NewScanner(b []byte, opts ...Option) *Scanner {
+	cf, err := newBytesParser(b, opts)
+	cf.keepComment = true
+	s := &Scanner{cf: cf, err: err}
+	if cf.opts.onComplete != nil {
+		s.start = time.Now()
+	}
+	return s
+}
+
+// Scan returns the next token, its text and the line it starts on. A key
+// is followed by its value, if it has one, and then by its comment, if
+// any. At the end of the input Scan returns TokenEOF and the number of
+// lines, on an error TokenError and the line of the error.
+func (s *Scanner) Scan() (TokenType, string, uint) {
+	if len(s.pending) > 0 {
+		t := s.pending[0]
+		s.pending = s.pending[1:]
+		return t.typ, t.text, t.line
+	}
+	if s.err != nil {
+		return s.errorToken()
+	}
+	cf := s.cf
+	ev, text, value, err := cf.step()
+	if err != nil {
+		s.err = err
+		if cf.err != nil {
+			s.err = cf.err
+		}
+		return s.errorToken()
+	}
+	line := cf.keyLine
+	switch ev {
+	case eventSection:
+		return TokenSection, text, line
+	case eventComment:
+		return TokenComment, text, line
+	case eventKey:
+		s.keys++
+		if !cf.bare || cf.opts.bareTrue {
+			s.pending = append(s.pending, scanToken{TokenValue, value, line})
+		}
+		if len(cf.comment) > 0 {
+			s.pending = append(s.pending, scanToken{TokenComment, string(cf.comment), cf.line})
+		}
+		return TokenKey, text, line
+	}
+	if cf.err != nil {
+		s.err = cf.err
+		return s.errorToken()
+	}
+	s.complete(nil)
+	return TokenEOF, "", cf.lineno()
+}
+
+// Err returns the error that made Scan return TokenError, or nil. Syntax
+// errors are a *ParseError, as returned by Parse.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// complete calls the OnComplete callback once the input is consumed.
+func (s *Scanner) complete(err error) {
+	fn := s.cf.opts.onComplete
+	if fn == nil || s.completed {
+		return
+	}
+	s.completed = true
+	fn(ParseStats{
+		Lines:    s.cf.lineno(),
+		Keys:     s.keys,
+		Bytes:    s.cf.offset,
+		Duration: time.Since(s.start),
+		Err:      err,
+	})
+}
+
+func (s *Scanner) errorToken() (TokenType, string, uint) {
+	s.complete(s.err)
+	var perr *ParseError
+	if errors.As(s.err, &perr) {
+		return TokenError, "", perr.Line
+	}
+	return TokenError, "", s.cf.lineno()
+}
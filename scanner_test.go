@@ -0,0 +1,245 @@
+package goconfig
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type scanned struct {
+	typ  TokenType
+	text string
+	line uint
+}
+
+func scanAll(s *Scanner) []scanned {
+	var tokens []scanned
+	for {
+		typ, text, line := s.Scan()
+		tokens = append(tokens, scanned{typ, text, line})
+		if typ == TokenEOF || typ == TokenError {
+			return tokens
+		}
+	}
+}
+
+func TestScanner(t *testing.T) {
+	tokens := scanAll(NewScanner([]byte(commentedConfig)))
+	assert.Equal(t, []scanned{
+		{TokenComment, "# global settings", 1},
+		{TokenSection, "user", 2},
+		{TokenComment, "; me", 2},
+		{TokenKey, "name", 3},
+		{TokenValue, "Danyel", 3},
+		{TokenComment, "# inline", 3},
+		{TokenKey, "email", 5},
+		{TokenValue, "cydrop@gmail.com", 5},
+		{TokenSection, "remote.origin", 6},
+		{TokenKey, "url", 6},
+		{TokenValue, "a   b", 6},
+		{TokenComment, "; trailing", 8},
+		{TokenSection, "core", 10},
+		{TokenKey, "bare", 11},
+		{TokenEOF, "", 11},
+	}, tokens)
+
+	s := NewScanner([]byte("[Core]\n\tEditor = \"vi\" ;x\n\tempty =\n"), KeepCase())
+	assert.Equal(t, []scanned{
+		{TokenSection, "Core", 1},
+		{TokenKey, "Editor", 2},
+		{TokenValue, "vi", 2},
+		{TokenComment, ";x", 2},
+		{TokenKey, "empty", 3},
+		{TokenValue, "", 3},
+		{TokenEOF, "", 3},
+	}, scanAll(s))
+	typ, _, _ := s.Scan()
+	assert.Equal(t, TokenEOF, typ)
+	assert.Equal(t, nil, s.Err())
+}
+
+func TestScannerError(t *testing.T) {
+	s := NewScanner([]byte("[user]\n\tname = x\n\tna@me = y\n\tok = z\n"))
+	assert.Equal(t, []scanned{
+		{TokenSection, "user", 1},
+		{TokenKey, "name", 2},
+		{TokenValue, "x", 2},
+		{TokenError, "", 3},
+	}, scanAll(s))
+	typ, _, line := s.Scan()
+	assert.Equal(t, TokenError, typ)
+	assert.Equal(t, uint(3), line)
+	_, _, expected := Parse([]byte("[user]\n\tname = x\n\tna@me = y\n\tok = z\n"))
+	assert.Equal(t, expected, s.Err())
+
+	s = NewScanner([]byte("\xef\xbb"))
+	typ, _, _ = s.Scan()
+	assert.Equal(t, TokenError, typ)
+	assert.True(t, errors.Is(s.Err(), ErrPartialBOM))
+
+	s = NewScanner([]byte("[a]\n\tb = c\n\td = e\n"), MaxLines(2))
+	assert.Equal(t, []scanned{
+		{TokenSection, "a", 1},
+		{TokenKey, "b", 2},
+		{TokenValue, "c", 2},
+		{TokenError, "", 3},
+	}, scanAll(s))
+	assert.True(t, errors.Is(s.Err(), ErrLimitExceeded))
+
+	s = NewScanner([]byte("[a]\n  = x\n"))
+	assert.Equal(t, TokenError, scanAll(s)[1].typ)
+	_, _, expected = Parse([]byte("[a]\n  = x\n"))
+	assert.ErrorIs(t, s.Err(), ErrEmptyKey)
+	assert.Equal(t, expected, s.Err())
+}
+
+// TestScannerMatchesParse rebuilds the map of Parse from the tokens.
+func TestScannerMatchesParse(t *testing.T) {
+	b, err := ioutil.ReadFile("configs/danyel.gitconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range []string{string(b), commentedConfig, listConfig} {
+		expected, lineno, err := Parse([]byte(input))
+		assert.Equal(t, nil, err)
+		config := map[string]string{}
+		section, key := "", ""
+		s := NewScanner([]byte(input))
+		for {
+			typ, text, line := s.Scan()
+			switch typ {
+			case TokenSection:
+				section = text
+			case TokenKey:
+				key = section + "." + text
+				config[key] = ""
+			case TokenValue:
+				config[key] = text
+			}
+			if typ == TokenEOF {
+				assert.Equal(t, lineno, line)
+				break
+			}
+			if !assert.NotEqual(t, TokenError, typ, s.Err()) {
+				break
+			}
+		}
+		assert.Equal(t, expected, config)
+	}
+	assert.Equal(t, "Comment", TokenComment.String())
+	assert.Equal(t, "TokenType(9)", TokenType(9).String())
+}
+
+// scanConfig rebuilds the map of Parse from the tokens of s.
+func scanConfig(s *Scanner) (map[string]string, uint, error) {
+	config := map[string]string{}
+	section, key := "", ""
+	for {
+		typ, text, line := s.Scan()
+		switch typ {
+		case TokenSection:
+			section = text
+		case TokenKey:
+			key = section + "." + text
+			config[key] = ""
+		case TokenValue:
+			config[key] = text
+		case TokenEOF:
+			return config, line, nil
+		case TokenError:
+			return config, line, s.Err()
+		}
+	}
+}
+
+// TestScannerOptions checks that Scan applies every Option like Parse.
+func TestScannerOptions(t *testing.T) {
+	input := "[core]\n" +
+		"\tbare\n" +
+		"\tEditor = vi # a ; b // c\n" +
+		"\tpath = \"x\"  \\q\\x41\n" +
+		"\tspaced=a\n" +
+		"[remote \"origin\"]\n" +
+		"\turl = https://example.com\n" +
+		"[core]\n" +
+		"\tlast = caf\xc3\n"
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{"BareKeysAsTrue", BareKeysAsTrue()},
+		{"MaxBytes", MaxBytes(40)},
+		{"MaxLines", MaxLines(3)},
+		{"RejectDuplicateSections", RejectDuplicateSections()},
+		{"RejectLegacySections", RejectLegacySections()},
+		{"KeepCase", KeepCase()},
+		{"LenientEscapes", LenientEscapes()},
+		{"HexEscapes", HexEscapes()},
+		{"NoInlineComments", NoInlineComments()},
+		{"SlashComments", SlashComments()},
+		{"DisableHashComments", DisableHashComments()},
+		{"DisableSemicolonComments", DisableSemicolonComments()},
+		{"WithSections", WithSections("remote")},
+		{"RawValues", RawValues()},
+		{"RejectInvalidUTF8", RejectInvalidUTF8()},
+		{"CheckSpacing", CheckSpacing(func(SpacingWarning) {})},
+		{"OnComplete", OnComplete(func(ParseStats) {})},
+		{"MaxIncludeDepth", MaxIncludeDepth(1)},
+		{"WithIncludeContext", WithIncludeContext(IncludeContext{Branch: "main"})},
+	}
+	for _, test := range tests {
+		for _, opts := range [][]Option{
+			{test.opt},
+			{test.opt, LenientEscapes()},
+			{test.opt, LenientEscapes(), HexEscapes()},
+		} {
+			expected, lineno, expectedErr := Parse([]byte(input), opts...)
+			config, line, err := scanConfig(NewScanner([]byte(input), opts...))
+			assert.Equal(t, expectedErr, err, test.name)
+			assert.Equal(t, lineno, line, test.name)
+			if expectedErr == nil {
+				assert.Equal(t, expected, config, test.name)
+			}
+		}
+	}
+
+	_, _, err := scanConfig(NewScanner([]byte("[a]\n\tb = 1\n[a]\n"), RejectDuplicateSections()))
+	assert.ErrorIs(t, err, ErrDuplicateSection)
+
+	tokens := scanAll(NewScanner([]byte("# x\n[core]\n\tbare\n[remote \"o\"] ; y\n\turl = u\n"),
+		WithSections("remote"), BareKeysAsTrue()))
+	assert.Equal(t, []scanned{
+		{TokenComment, "# x", 1},
+		{TokenSection, "remote.o", 4},
+		{TokenComment, "; y", 4},
+		{TokenKey, "url", 5},
+		{TokenValue, "u", 5},
+		{TokenEOF, "", 5},
+	}, tokens)
+	tokens = scanAll(NewScanner([]byte("[core]\n\tbare\n"), BareKeysAsTrue()))
+	assert.Equal(t, []scanned{
+		{TokenSection, "core", 1},
+		{TokenKey, "bare", 2},
+		{TokenValue, "true", 2},
+		{TokenEOF, "", 2},
+	}, tokens)
+
+	var stats []ParseStats
+	s := NewScanner([]byte(input), LenientEscapes(), OnComplete(func(st ParseStats) { stats = append(stats, st) }))
+	scanAll(s)
+	s.Scan()
+	if assert.Len(t, stats, 1) {
+		assert.Equal(t, 6, stats[0].Keys)
+		assert.Equal(t, uint(9), stats[0].Lines)
+		assert.Equal(t, int64(len(input)), stats[0].Bytes)
+		assert.Equal(t, nil, stats[0].Err)
+	}
+	stats = nil
+	s = NewScanner([]byte(input), LenientEscapes(), RejectDuplicateSections(), OnComplete(func(st ParseStats) { stats = append(stats, st) }))
+	scanAll(s)
+	if assert.Len(t, stats, 1) {
+		assert.ErrorIs(t, stats[0].Err, ErrDuplicateSection)
+	}
+}
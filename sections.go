@@ -0,0 +1,63 @@
+package goconfig
+
+import "strings"
+
+// SplitKey splits a flat key as stored by Parse into its parts, following
+// git: only the first and the last dot delimit, everything in between is
+// the subsection, dots included. So "url.https://x.y/.insteadof" has the
+// section "url", the subsection "https://x.y/" and the variable name
+// "insteadof", and "remote.origin.url" the subsection "origin". A key with
+// one dot has no subsection, a key without dot is a bare variable name.
+// The parts keep their case; use NormalizeKey to canonicalize or validate
+// a key first.
+func SplitKey(key string) (section, subsection, name string) {
+	first := strings.IndexByte(key, '.')
+	if first < 0 {
+		return "", "", key
+	}
+	last := strings.LastIndexByte(key, '.')
+	if first != last {
+		subsection = key[first+1 : last]
+	}
+	return key[:first], subsection, key[last+1:]
+}
+
+// Sections returns the sorted names of all sections that have a key in cfg.
+func Sections(cfg map[string]string) []string {
+	seen := map[string]bool{}
+	for key := range cfg {
+		if section, _, _ := SplitKey(key); section != "" {
+			seen[section] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// Subsections returns the sorted subsection names of section, e.g. the
+// configured remotes for "remote". section is matched case-insensitively,
+// subsections are returned with their case preserved.
+func Subsections(cfg map[string]string, section string) []string {
+	section = strings.ToLower(section)
+	seen := map[string]bool{}
+	for key := range cfg {
+		if s, subsection, _ := SplitKey(key); s == section && subsection != "" {
+			seen[subsection] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// Keys returns the sorted variable names set in the given section and
+// subsection, e.g. Keys(cfg, "remote", "origin") returns "fetch" and "url".
+// An empty subsection selects the keys outside any subsection. section is
+// matched case-insensitively, subsection exactly.
+func Keys(cfg map[string]string, section, subsection string) []string {
+	section = strings.ToLower(section)
+	seen := map[string]bool{}
+	for key := range cfg {
+		if s, sub, name := SplitKey(key); s == section && sub == subsection {
+			seen[name] = true
+		}
+	}
+	return sortedKeys(seen)
+}
@@ -0,0 +1,73 @@
+package goconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sectionsConfig = `[user]
+	name = Danyel
+	email = cydrop@gmail.com
+[remote "origin"]
+	url = https://example.com/repo.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+[remote "Upstream"]
+	url = https://example.com/upstream.git
+[remote "upstream"]
+	url = https://example.com/other.git
+[remote]
+	pushDefault = origin
+[url "https://x.y/"]
+	insteadOf = xy:`
+
+func TestSections(t *testing.T) {
+	config, _, err := Parse([]byte(sectionsConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"remote", "url", "user"}, Sections(config))
+	assert.Equal(t, []string{}, Sections(nil))
+}
+
+func TestSubsections(t *testing.T) {
+	config, _, err := Parse([]byte(sectionsConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"Upstream", "origin", "upstream"}, Subsections(config, "Remote"))
+	assert.Equal(t, []string{"https://x.y/"}, Subsections(config, "url"))
+	assert.Equal(t, []string{}, Subsections(config, "user"))
+}
+
+func TestKeys(t *testing.T) {
+	config, _, err := Parse([]byte(sectionsConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"email", "name"}, Keys(config, "user", ""))
+	assert.Equal(t, []string{"fetch", "url"}, Keys(config, "Remote", "origin"))
+	assert.Equal(t, []string{"url"}, Keys(config, "remote", "Upstream"))
+	assert.Equal(t, []string{"pushdefault"}, Keys(config, "remote", ""))
+	assert.Equal(t, []string{"insteadof"}, Keys(config, "url", "https://x.y/"))
+	assert.Equal(t, []string{}, Keys(config, "remote", "missing"))
+}
+
+func TestSplitKey(t *testing.T) {
+	config, _, err := Parse([]byte(sectionsConfig + "\n[url \"git@a.b:c.d\"]\n\tpushInsteadOf = x\n[a \"\"]\n\tb = c"))
+	assert.Equal(t, nil, err)
+	tests := map[string][3]string{
+		"user.name":                     {"user", "", "name"},
+		"remote.origin.url":             {"remote", "origin", "url"},
+		"remote.Upstream.url":           {"remote", "Upstream", "url"},
+		"url.https://x.y/.insteadof":    {"url", "https://x.y/", "insteadof"},
+		"url.git@a.b:c.d.pushinsteadof": {"url", "git@a.b:c.d", "pushinsteadof"},
+		"a..b":                          {"a", "", "b"},
+		"remote.pushdefault":            {"remote", "", "pushdefault"},
+	}
+	for key, expected := range tests {
+		_, ok := config[key]
+		assert.True(t, ok, key)
+		section, subsection, name := SplitKey(key)
+		assert.Equal(t, expected, [3]string{section, subsection, name}, key)
+	}
+
+	section, subsection, name := SplitKey("Remote.Origin.URL")
+	assert.Equal(t, [3]string{"Remote", "Origin", "URL"}, [3]string{section, subsection, name})
+	section, subsection, name = SplitKey("nodot")
+	assert.Equal(t, [3]string{"", "", "nodot"}, [3]string{section, subsection, name})
+}
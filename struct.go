@@ -0,0 +1,149 @@
+package goconfig
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const tagName = "gitconfig"
+
+// Unmarshal parses bytes and stores the values in the struct pointed to by
+// v. Fields are mapped to keys with a tag, which is normalized as in Get:
+//
+//	type User struct {
+//		Name  string   `gitconfig:"user.name"`
+//		Bare  bool     `gitconfig:"core.bare"`
+//		Limit int64    `gitconfig:"pack.packSizeLimit"`
+//		Fetch []string `gitconfig:"remote.origin.fetch"`
+//	}
+//
+// string fields receive the value as is, bool fields are decoded like
+// GetBool and int fields like GetInt64, including the unit suffixes.
+// []string fields receive every value of a multi-valued key in order; the
+// other types use the last value. Fields whose key is not set are left
+// unchanged, untagged and unexported fields are ignored. A value that
+// cannot be decoded returns an error naming the field and the key.
+func Unmarshal(b []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrInvalidTarget
+	}
+	multi, _, err := ParseMulti(b)
+	if err != nil {
+		return err
+	}
+	cfg := make(map[string]string, len(multi))
+	for key, values := range multi {
+		cfg[key] = values[len(values)-1]
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get(tagName), ",")
+		if key == "" || !field.IsExported() {
+			continue
+		}
+		if err := decodeField(rv.Field(i), cfg, multi, key); err != nil {
+			return fmt.Errorf("goconfig: field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+func decodeField(fv reflect.Value, cfg map[string]string, multi map[string][]string, key string) error {
+	if _, ok := cfg[normalizeKey(key)]; !ok {
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(cfg[normalizeKey(key)])
+	case reflect.Bool:
+		b, err := GetBool(cfg, key)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := GetInt64(cfg, key)
+		if err != nil {
+			return err
+		}
+		if fv.OverflowInt(n) {
+			return fmt.Errorf("%w for %s: %d", ErrOutOfRange, key, n)
+		}
+		fv.SetInt(n)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%w %s for %s", ErrUnsupportedType, fv.Type(), key)
+		}
+		values := multi[normalizeKey(key)]
+		fv.Set(reflect.ValueOf(append([]string(nil), values...)).Convert(fv.Type()))
+	default:
+		return fmt.Errorf("%w %s for %s", ErrUnsupportedType, fv.Type(), key)
+	}
+	return nil
+}
+
+// MarshalStruct serializes the tagged fields of v, a struct or a pointer to
+// one, in the format of Marshal. It uses the same tags and field types as
+// Unmarshal; []string fields are written as one key per element. With the
+// tag option omitempty, as in `gitconfig:"user.name,omitempty"`, a field
+// holding the zero value is left out.
+func MarshalStruct(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrInvalidTarget
+	}
+	cfg := map[string][]string{}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		key, opts, _ := strings.Cut(field.Tag.Get(tagName), ",")
+		if key == "" || !field.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+		if opts == "omitempty" && fv.IsZero() {
+			continue
+		}
+		values, err := encodeField(fv)
+		if err != nil {
+			return nil, fmt.Errorf("goconfig: field %s: %w for %s", field.Name, err, key)
+		}
+		key = normalizeKey(key)
+		cfg[key] = append(cfg[key], values...)
+	}
+	var buf bytes.Buffer
+	if _, err := writeMulti(&buf, cfg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeField(fv reflect.Value) ([]string, error) {
+	switch fv.Kind() {
+	case reflect.String:
+		return []string{fv.String()}, nil
+	case reflect.Bool:
+		return []string{strconv.FormatBool(fv.Bool())}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(fv.Int(), 10)}, nil
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() == reflect.String {
+			values := make([]string, fv.Len())
+			for i := range values {
+				values[i] = fv.Index(i).String()
+			}
+			return values, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %s", ErrUnsupportedType, fv.Type())
+}
@@ -0,0 +1,159 @@
+package goconfig
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	Name     string   `gitconfig:"user.name"`
+	Email    string   `gitconfig:"User.Email"`
+	Editor   string   `gitconfig:"core.editor"`
+	Bare     bool     `gitconfig:"core.bare"`
+	Abbrev   int      `gitconfig:"core.abbrev"`
+	Limit    int64    `gitconfig:"pack.packSizeLimit"`
+	Fetch    []string `gitconfig:"remote.origin.fetch"`
+	Missing  string   `gitconfig:"user.missing"`
+	Untagged string
+	ignored  string `gitconfig:"user.name"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	input := `[user]
+	name = Danyel
+	email = cydrop@gmail.com
+[core]
+	bare
+	abbrev = 12
+[pack]
+	packSizeLimit = 2g
+[remote "origin"]
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	fetch = +refs/tags/*:refs/tags/*`
+	cfg := testConfig{Missing: "kept", Untagged: "kept"}
+	err := Unmarshal([]byte(input), &cfg)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, testConfig{
+		Name:     "Danyel",
+		Email:    "cydrop@gmail.com",
+		Bare:     true,
+		Abbrev:   12,
+		Limit:    2 * 1024 * 1024 * 1024,
+		Fetch:    []string{"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"},
+		Missing:  "kept",
+		Untagged: "kept",
+	}, cfg)
+}
+
+func TestUnmarshalFile(t *testing.T) {
+	bytes, err := ioutil.ReadFile("configs/danyel.gitconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg testConfig
+	assert.Equal(t, nil, Unmarshal(bytes, &cfg))
+	assert.Equal(t, "Danyel Bayraktar", cfg.Name)
+	assert.Equal(t, "subl -w", cfg.Editor)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var cfg testConfig
+	err := Unmarshal([]byte("[core]\n\tbare = maybe"), &cfg)
+	assert.ErrorIs(t, err, ErrInvalidBool)
+	assert.Contains(t, err.Error(), "Bare")
+	assert.Contains(t, err.Error(), "core.bare")
+
+	err = Unmarshal([]byte("[core]\n\tabbrev = twelve"), &cfg)
+	assert.ErrorIs(t, err, ErrInvalidInt)
+	assert.Contains(t, err.Error(), "Abbrev")
+
+	var small struct {
+		Small int8 `gitconfig:"core.small"`
+	}
+	err = Unmarshal([]byte("[core]\n\tsmall = 1k"), &small)
+	assert.ErrorIs(t, err, ErrOutOfRange)
+
+	var unsupported struct {
+		Ratio float64 `gitconfig:"core.ratio"`
+	}
+	err = Unmarshal([]byte("[core]\n\tratio = 0.5"), &unsupported)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	err = Unmarshal([]byte("[core"), &cfg)
+	assert.ErrorIs(t, err, ErrUnexpectedEOF)
+
+	assert.ErrorIs(t, Unmarshal(nil, cfg), ErrInvalidTarget)
+	assert.ErrorIs(t, Unmarshal(nil, (*testConfig)(nil)), ErrInvalidTarget)
+	assert.ErrorIs(t, Unmarshal(nil, new(string)), ErrInvalidTarget)
+}
+
+func TestMarshalStruct(t *testing.T) {
+	cfg := testConfig{
+		Name:   "Danyel",
+		Email:  "cydrop@gmail.com",
+		Editor: "subl -w",
+		Bare:   true,
+		Abbrev: 12,
+		Limit:  1 << 31,
+		Fetch:  []string{"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"},
+	}
+	out, err := MarshalStruct(&cfg)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `[core]
+	abbrev = 12
+	bare = true
+	editor = subl -w
+[pack]
+	packsizelimit = 2147483648
+[remote "origin"]
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	fetch = +refs/tags/*:refs/tags/*
+[user]
+	email = cydrop@gmail.com
+	missing = 
+	name = Danyel
+`, string(out))
+
+	var decoded testConfig
+	assert.Equal(t, nil, Unmarshal(out, &decoded))
+	assert.Equal(t, cfg, decoded)
+}
+
+func TestMarshalStructOmitEmpty(t *testing.T) {
+	type user struct {
+		Name   string   `gitconfig:"user.name,omitempty"`
+		Email  string   `gitconfig:"user.email"`
+		Signed bool     `gitconfig:"commit.gpgSign,omitempty"`
+		Abbrev int      `gitconfig:"core.abbrev,omitempty"`
+		Paths  []string `gitconfig:"include.path,omitempty"`
+	}
+	out, err := MarshalStruct(user{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "[user]\n\temail = \n", string(out))
+
+	out, err = MarshalStruct(user{Name: "Danyel  ", Paths: []string{"a", "b"}})
+	assert.Equal(t, nil, err)
+	var decoded user
+	assert.Equal(t, nil, Unmarshal(out, &decoded))
+	assert.Equal(t, user{Name: "Danyel  ", Paths: []string{"a", "b"}}, decoded)
+}
+
+func TestMarshalStructErrors(t *testing.T) {
+	_, err := MarshalStruct("user")
+	assert.ErrorIs(t, err, ErrInvalidTarget)
+	_, err = MarshalStruct((*testConfig)(nil))
+	assert.ErrorIs(t, err, ErrInvalidTarget)
+
+	_, err = MarshalStruct(struct {
+		Ratio float64 `gitconfig:"core.ratio"`
+	}{0.5})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	assert.Contains(t, err.Error(), "Ratio")
+
+	_, err = MarshalStruct(struct {
+		Bad string `gitconfig:"nosection"`
+	}{"x"})
+	assert.ErrorIs(t, err, ErrInvalidKeyChar)
+}
@@ -0,0 +1,139 @@
+package goconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Config is a configuration grouped by section, subsection and key:
+// Sections["remote"]["origin"]["url"]. Section and key names are lowercase,
+// subsections keep their case. Keys outside a `[section "subsection"]`
+// block are stored under the empty subsection.
+type Config struct {
+	Sections map[string]map[string]map[string]string
+}
+
+// ParseTree parses the given bytes like Parse, but returns the variables
+// grouped by section and subsection.
+func ParseTree(b []byte, opts ...Option) (*Config, uint, error) {
+	cfg := &Config{Sections: map[string]map[string]map[string]string{}}
+	lineno, err := parseBytes(b, func(name, key, value string) error {
+		section, subsection := splitSection(name)
+		cfg.set(section, subsection, key, value)
+		return nil
+	}, opts)
+	return cfg, lineno, err
+}
+
+// Load reads the file at path and parses it like ParseTree. Errors from
+// reading the file are wrapped as in ParseFile.
+func Load(path string, opts ...Option) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("goconfig: %w", err)
+	}
+	cfg, _, err := ParseTree(b, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// String returns the value of key, normalized as in Get, or an
+// ErrKeyNotFound error.
+func (c *Config) String(key string) (string, error) {
+	return lookup(c.entry(key), key)
+}
+
+// Bool returns the value of key as a boolean, like GetBool.
+func (c *Config) Bool(key string) (bool, error) {
+	return GetBool(c.entry(key), key)
+}
+
+// Int returns the value of key as an int, like GetInt.
+func (c *Config) Int(key string) (int, error) {
+	return GetInt(c.entry(key), key)
+}
+
+// Path returns the value of key as a path, like GetPath.
+func (c *Config) Path(key string) (string, error) {
+	return GetPath(c.entry(key), key)
+}
+
+// All returns the variables of section, including those in its
+// subsections, as a flat map like the one returned by Parse. The section
+// name is matched case-insensitively.
+func (c *Config) All(section string) map[string]string {
+	section = strings.ToLower(section)
+	cfg := map[string]string{}
+	for subsection, keys := range c.Sections[section] {
+		prefix := joinSection(section, subsection)
+		for key, value := range keys {
+			cfg[prefix+key] = value
+		}
+	}
+	return cfg
+}
+
+// entry returns a flat map holding only key, so that the free getters can
+// be used on the tree.
+func (c *Config) entry(key string) map[string]string {
+	key = normalizeKey(key)
+	section, subsection, name := SplitKey(key)
+	value, ok := c.Sections[section][subsection][name]
+	if !ok {
+		return nil
+	}
+	return map[string]string{key: value}
+}
+
+func (c *Config) set(section, subsection, key, value string) {
+	subsections, ok := c.Sections[section]
+	if !ok {
+		subsections = map[string]map[string]string{}
+		c.Sections[section] = subsections
+	}
+	keys, ok := subsections[subsection]
+	if !ok {
+		keys = map[string]string{}
+		subsections[subsection] = keys
+	}
+	keys[key] = value
+}
+
+// Flatten returns the configuration as the flat map returned by Parse.
+func (c *Config) Flatten() map[string]string {
+	cfg := map[string]string{}
+	for section, subsections := range c.Sections {
+		for subsection, keys := range subsections {
+			prefix := joinSection(section, subsection)
+			for key, value := range keys {
+				cfg[prefix+key] = value
+			}
+		}
+	}
+	return cfg
+}
+
+// splitSection splits a section prefix as passed to a setter into the
+// section and the subsection. Everything after the first dot belongs to
+// the subsection.
+func splitSection(name string) (section, subsection string) {
+	name = strings.TrimSuffix(name, ".")
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return name, ""
+}
+
+// joinSection is the inverse of splitSection.
+func joinSection(section, subsection string) string {
+	switch {
+	case section == "":
+		return ""
+	case subsection == "":
+		return section + "."
+	}
+	return section + "." + subsection + "."
+}
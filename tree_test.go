@@ -0,0 +1,118 @@
+package goconfig
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTree(t *testing.T) {
+	validConfig := `[user]
+	name = Danyel
+[remote "Origin"]
+	URL = https://example.com/repo.git
+[http "https://my-website.com"]
+	sslVerify = false
+[Legacy.Sub]
+	key = value`
+	config, lineno, err := ParseTree([]byte(validConfig))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 8, int(lineno))
+	assert.Equal(t, map[string]map[string]map[string]string{
+		"user":   {"": {"name": "Danyel"}},
+		"remote": {"Origin": {"url": "https://example.com/repo.git"}},
+		"http":   {"https://my-website.com": {"sslverify": "false"}},
+		"legacy": {"sub": {"key": "value"}},
+	}, config.Sections)
+}
+
+func TestFlatten(t *testing.T) {
+	filename := "configs/danyel.gitconfig"
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Reading file %v failed", filename)
+	}
+	expected, _, _ := Parse(bytes)
+	config, _, err := ParseTree(bytes)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, expected, config.Flatten())
+
+	extended := []byte(`[http "https://my-website.com"] sslVerify = false`)
+	expected, _, _ = Parse(extended)
+	config, _, err = ParseTree(extended)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, expected, config.Flatten())
+}
+
+func TestConfigAccessors(t *testing.T) {
+	validConfig := `[core]
+	bare
+	filemode = false
+	bigFileThreshold = 1k
+	excludesFile = ~/ignore
+[remote "Origin"]
+	url = https://example.com/repo.git
+	fetch = a
+	fetch = b`
+	config, _, err := ParseTree([]byte(validConfig))
+	assert.Equal(t, nil, err)
+
+	url, err := config.String("Remote.Origin.URL")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "https://example.com/repo.git", url)
+	fetch, err := config.String("remote.Origin.fetch")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "b", fetch)
+	_, err = config.String("remote.origin.url")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	assert.Equal(t, "key not found: remote.origin.url", err.Error())
+
+	b, err := config.Bool("core.bare")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, b)
+	b, err = config.Bool("core.fileMode")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, b)
+	_, err = config.Bool("core.bigfilethreshold")
+	assert.True(t, errors.Is(err, ErrInvalidBool))
+
+	n, err := config.Int("core.bigFileThreshold")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1024, n)
+	_, err = config.Int("core.missing")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	home, err := os.UserHomeDir()
+	if err == nil {
+		path, err := config.Path("core.excludesfile")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, home+"/ignore", path)
+	}
+
+	assert.Equal(t, map[string]string{
+		"remote.Origin.url":   "https://example.com/repo.git",
+		"remote.Origin.fetch": "b",
+	}, config.All("Remote"))
+	assert.Equal(t, map[string]string{}, config.All("missing"))
+}
+
+func TestLoad(t *testing.T) {
+	config, err := Load("configs/danyel.gitconfig")
+	assert.Equal(t, nil, err)
+	name, err := config.String("user.name")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Danyel Bayraktar", name)
+
+	_, err = Load("configs/missing.gitconfig")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	path := filepath.Join(t.TempDir(), "invalid")
+	assert.Equal(t, nil, os.WriteFile(path, []byte("[core\n"), 0o600))
+	_, err = Load(path)
+	var perr *ParseError
+	assert.True(t, errors.As(err, &perr))
+}
@@ -0,0 +1,83 @@
+package goconfig
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	typesMu sync.RWMutex
+	types   = map[string]func(string) (interface{}, error){}
+)
+
+// builtinTypes are the types GetTyped knows without registration, named
+// like the --type option of git config.
+var builtinTypes = map[string]func(cfg map[string]string, key string) (interface{}, error){
+	"bool": func(cfg map[string]string, key string) (interface{}, error) {
+		return GetBool(cfg, key)
+	},
+	"int": func(cfg map[string]string, key string) (interface{}, error) {
+		return GetInt(cfg, key)
+	},
+	"bool-or-int": func(cfg map[string]string, key string) (interface{}, error) {
+		isBool, b, i, err := GetBoolOrInt(cfg, key)
+		if err != nil || !isBool {
+			return i, err
+		}
+		return b, nil
+	},
+	"path": func(cfg map[string]string, key string) (interface{}, error) {
+		return GetPath(cfg, key)
+	},
+	"expiry-date": func(cfg map[string]string, key string) (interface{}, error) {
+		return GetExpiry(cfg, key)
+	},
+}
+
+// RegisterType makes fn available to GetTyped under name, e.g. a parser for
+// time.Duration or for comma-separated lists. It is safe to call from
+// several goroutines, but is meant to be called from init functions.
+// The built-in types bool, int, bool-or-int, path and expiry-date always
+// take precedence: RegisterType panics if name is one of them, if a type
+// of that name is already registered, or if fn is nil.
+func RegisterType(name string, fn func(string) (interface{}, error)) {
+	if fn == nil {
+		panic("goconfig: RegisterType parser is nil")
+	}
+	if _, ok := builtinTypes[name]; ok {
+		panic("goconfig: RegisterType called for built-in type " + name)
+	}
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	if _, ok := types[name]; ok {
+		panic("goconfig: RegisterType called twice for type " + name)
+	}
+	types[name] = fn
+}
+
+// GetTyped returns the value of key converted to the type called typeName:
+// one of the built-in types, which behave like GetBool, GetInt,
+// GetBoolOrInt (returning a bool or an int), GetPath and GetExpiry, or a
+// type added with RegisterType. An error returned by a registered parser
+// is wrapped together with the type and the key. An unknown type name
+// returns an ErrUnknownType error.
+func GetTyped(cfg map[string]string, key, typeName string) (interface{}, error) {
+	if get, ok := builtinTypes[typeName]; ok {
+		return get(cfg, key)
+	}
+	typesMu.RLock()
+	fn, ok := types[typeName]
+	typesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, typeName)
+	}
+	value, err := lookup(cfg, key)
+	if err != nil {
+		return nil, err
+	}
+	v, err := fn(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value for %s: %w", typeName, key, err)
+	}
+	return v, nil
+}
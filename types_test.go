@@ -0,0 +1,72 @@
+package goconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	RegisterType("duration", func(value string) (interface{}, error) {
+		return time.ParseDuration(value)
+	})
+	RegisterType("list", func(value string) (interface{}, error) {
+		return strings.Split(value, ","), nil
+	})
+}
+
+func TestGetTyped(t *testing.T) {
+	cfg := map[string]string{
+		"http.timeout": "1m30s",
+		"http.bad":     "soon",
+		"core.hooks":   "pre-commit,pre-push",
+		"core.bare":    "yes",
+		"core.level":   "9",
+		"gc.expire":    "never",
+	}
+
+	v, err := GetTyped(cfg, "http.timeout", "duration")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 90*time.Second, v)
+
+	v, err = GetTyped(cfg, "core.hooks", "list")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"pre-commit", "pre-push"}, v)
+
+	_, err = GetTyped(cfg, "http.bad", "duration")
+	assert.EqualError(t, err, `invalid duration value for http.bad: time: invalid duration "soon"`)
+
+	_, err = GetTyped(cfg, "http.missing", "duration")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	_, err = GetTyped(cfg, "http.timeout", "nosuchtype")
+	assert.True(t, errors.Is(err, ErrUnknownType))
+
+	v, err = GetTyped(cfg, "core.bare", "bool")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, v)
+	v, err = GetTyped(cfg, "core.level", "int")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 9, v)
+	v, err = GetTyped(cfg, "core.level", "bool-or-int")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 9, v)
+	v, err = GetTyped(cfg, "core.bare", "bool-or-int")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, v)
+	v, err = GetTyped(cfg, "gc.expire", "expiry-date")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Never, v)
+	_, err = GetTyped(cfg, "http.bad", "bool")
+	assert.True(t, errors.Is(err, ErrInvalidBool))
+}
+
+func TestRegisterTypePanics(t *testing.T) {
+	parse := func(value string) (interface{}, error) { return value, nil }
+	assert.Panics(t, func() { RegisterType("bool", parse) })
+	assert.Panics(t, func() { RegisterType("duration", parse) })
+	assert.Panics(t, func() { RegisterType("new", nil) })
+}
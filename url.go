@@ -0,0 +1,77 @@
+package goconfig
+
+import "strings"
+
+// RewriteURL applies the url.<base>.insteadOf rules of cfg to url, like git
+// does for remote URLs: if url starts with the value of an insteadOf key,
+// that prefix is replaced by <base>. Of several matching rules the one with
+// the longest prefix wins. url is returned unchanged if no rule matches.
+//
+// A flat map holds only the last insteadOf of each base, so of a [url]
+// block that lists several only the last one applies; use RewriteURLMulti
+// with the result of ParseMulti to apply all of them.
+func RewriteURL(cfg map[string]string, url string) string {
+	return RewriteURLMulti(toMulti(cfg), url)
+}
+
+// RewritePushURL rewrites url for pushing: it applies the
+// url.<base>.pushInsteadOf rules as RewriteURL applies insteadOf, and falls
+// back to the insteadOf rules if none matches, like git. Like RewriteURL,
+// it sees only the last rule of each base; see RewritePushURLMulti.
+func RewritePushURL(cfg map[string]string, url string) string {
+	return RewritePushURLMulti(toMulti(cfg), url)
+}
+
+// RewriteURLMulti is like RewriteURL for a configuration as returned by
+// ParseMulti, in which every insteadOf value of a base is a rule.
+func RewriteURLMulti(cfg map[string][]string, url string) string {
+	rewritten, _ := rewriteURL(cfg, url, "insteadof")
+	return rewritten
+}
+
+// RewritePushURLMulti is like RewritePushURL for a configuration as
+// returned by ParseMulti.
+func RewritePushURLMulti(cfg map[string][]string, url string) string {
+	if rewritten, ok := rewriteURL(cfg, url, "pushinsteadof"); ok {
+		return rewritten
+	}
+	return RewriteURLMulti(cfg, url)
+}
+
+// rewriteURL applies the rules stored under url.<base>.<name>.
+func rewriteURL(cfg map[string][]string, url, name string) (string, bool) {
+	base, prefix, found := "", "", false
+	for key, values := range cfg {
+		if !strings.HasPrefix(key, "url.") {
+			continue
+		}
+		section, subsection, variable := SplitKey(key)
+		if section != "url" || variable != name || subsection == "" {
+			continue
+		}
+		for _, value := range values {
+			if !strings.HasPrefix(url, value) {
+				continue
+			}
+			// Ties are broken by the base, so that the result does not
+			// depend on map order.
+			if !found || len(value) > len(prefix) || len(value) == len(prefix) && subsection < base {
+				base, prefix, found = subsection, value, true
+			}
+		}
+	}
+	if !found {
+		return url, false
+	}
+	return base + url[len(prefix):], true
+}
+
+// toMulti returns cfg as a map with one value per key, as ParseMulti
+// returns it.
+func toMulti(cfg map[string]string) map[string][]string {
+	multi := make(map[string][]string, len(cfg))
+	for key, value := range cfg {
+		multi[key] = []string{value}
+	}
+	return multi
+}
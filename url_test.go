@@ -0,0 +1,89 @@
+package goconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewriteURL(t *testing.T) {
+	config, _, err := ParseString(`[url "git@github.com:"]
+	insteadOf = https://github.com/
+	pushInsteadOf = gh:
+[url "git@github.com:corp/"]
+	insteadOf = https://github.com/corp/
+[url "https://mirror.example.com/"]
+	insteadOf = https://
+[url "ssh://push.example.com/"]
+	pushInsteadOf = https://example.com/`)
+	assert.Equal(t, nil, err)
+
+	for url, expected := range map[string]string{
+		"https://github.com/muja/goconfig": "git@github.com:muja/goconfig",
+		"https://github.com/corp/tool.git": "git@github.com:corp/tool.git",
+		"https://example.com/repo.git":     "https://mirror.example.com/example.com/repo.git",
+		"gh:muja/goconfig":                 "gh:muja/goconfig",
+		"file:///tmp/repo":                 "file:///tmp/repo",
+	} {
+		assert.Equal(t, expected, RewriteURL(config, url), url)
+	}
+
+	for url, expected := range map[string]string{
+		"gh:muja/goconfig":                 "git@github.com:muja/goconfig",
+		"https://example.com/repo.git":     "ssh://push.example.com/repo.git",
+		"https://github.com/corp/tool.git": "git@github.com:corp/tool.git",
+		"file:///tmp/repo":                 "file:///tmp/repo",
+	} {
+		assert.Equal(t, expected, RewritePushURL(config, url), url)
+	}
+
+	assert.Equal(t, "https://x", RewriteURL(nil, "https://x"))
+}
+
+func TestRewriteURLTie(t *testing.T) {
+	config := map[string]string{
+		"url.b:.insteadof": "x:",
+		"url.a:.insteadof": "x:",
+		"url..insteadof":   "x:",
+		"urlx.c.insteadof": "x:",
+	}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "a:repo", RewriteURL(config, "x:repo"))
+	}
+}
+
+func TestRewriteURLMulti(t *testing.T) {
+	input := `[url "git@github.com:"]
+	insteadOf = https://github.com/
+	insteadOf = gh:
+	pushInsteadOf = https://push.github.com/
+	pushInsteadOf = ghp:
+[url "https://mirror.example.com/"]
+	insteadOf = https://
+`
+	config, _, err := ParseMulti([]byte(input))
+	assert.Equal(t, nil, err)
+	for url, expected := range map[string]string{
+		"https://github.com/x": "git@github.com:x",
+		"gh:x":                 "git@github.com:x",
+		"https://example.com/": "https://mirror.example.com/example.com/",
+		"ghp:x":                "ghp:x",
+	} {
+		assert.Equal(t, expected, RewriteURLMulti(config, url), url)
+	}
+	for url, expected := range map[string]string{
+		"https://push.github.com/x": "git@github.com:x",
+		"ghp:x":                     "git@github.com:x",
+		"gh:x":                      "git@github.com:x",
+		"https://github.com/x":      "git@github.com:x",
+	} {
+		assert.Equal(t, expected, RewritePushURLMulti(config, url), url)
+	}
+
+	// The flat map keeps only the last rule of a base.
+	flat, _, err := ParseString(input)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "git@github.com:x", RewriteURL(flat, "gh:x"))
+	assert.Equal(t, "https://mirror.example.com/github.com/x", RewriteURL(flat, "https://github.com/x"))
+	assert.Equal(t, "https://x", RewriteURLMulti(nil, "https://x"))
+}
@@ -0,0 +1,261 @@
+package goconfig
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// lookup returns the value of key, normalized as in Get, or an
+// ErrKeyNotFound error naming the key.
+func lookup(cfg map[string]string, key string) (string, error) {
+	value, ok := cfg[normalizeKey(key)]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	return value, nil
+}
+
+// GetBool returns the value of key as a boolean, following git: true, yes,
+// on, 1 and the empty value (a key without '=') are true; false, no, off, 0
+// are false. Case is ignored. Other values return an ErrInvalidBool error.
+func GetBool(cfg map[string]string, key string) (bool, error) {
+	value, err := lookup(cfg, key)
+	if err != nil {
+		return false, err
+	}
+	b, ok := parseBool(value)
+	if !ok {
+		return false, fmt.Errorf("%w for %s: %q", ErrInvalidBool, key, value)
+	}
+	return b, nil
+}
+
+func parseBool(value string) (b, ok bool) {
+	switch strings.ToLower(value) {
+	case "", "true", "yes", "on", "1":
+		return true, true
+	case "false", "no", "off", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// BoolState is a boolean setting that may be unset, as returned by
+// GetBoolState.
+type BoolState int
+
+const (
+	// BoolUnset means the key is not set, e.g. to inherit a default.
+	BoolUnset BoolState = iota
+	// BoolTrue means the key is set to a true value.
+	BoolTrue
+	// BoolFalse means the key is set to a false value.
+	BoolFalse
+)
+
+func (s BoolState) String() string {
+	switch s {
+	case BoolUnset:
+		return "unset"
+	case BoolTrue:
+		return "true"
+	case BoolFalse:
+		return "false"
+	}
+	return "BoolState(" + strconv.Itoa(int(s)) + ")"
+}
+
+// GetBoolState returns the value of key as a boolean like GetBool, but
+// tells an unset key apart in the result: it returns BoolUnset and no
+// error where GetBool returns an ErrKeyNotFound error. The values are
+// those of GetBool, so the empty value is BoolTrue, and other values
+// return BoolUnset with an ErrInvalidBool error.
+func GetBoolState(cfg map[string]string, key string) (BoolState, error) {
+	value, ok := cfg[normalizeKey(key)]
+	if !ok {
+		return BoolUnset, nil
+	}
+	b, ok := parseBool(value)
+	switch {
+	case !ok:
+		return BoolUnset, fmt.Errorf("%w for %s: %q", ErrInvalidBool, key, value)
+	case b:
+		return BoolTrue, nil
+	}
+	return BoolFalse, nil
+}
+
+// GetInt64 returns the value of key as an integer. The number may be
+// decimal or hexadecimal (0x prefix) and may carry one of the unit suffixes
+// k, m or g (case-insensitive), which multiply it by 1024, 1024² and 1024³.
+// Values that overflow int64 return an ErrOutOfRange error.
+func GetInt64(cfg map[string]string, key string) (int64, error) {
+	value, err := lookup(cfg, key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := parseInt64(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w for %s: %q", err, key, value)
+	}
+	return n, nil
+}
+
+// GetInt returns the value of key as an int, using the same syntax as
+// GetInt64. Values that do not fit an int return an ErrOutOfRange error.
+func GetInt(cfg map[string]string, key string) (int, error) {
+	n, err := GetInt64(cfg, key)
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxInt || n < math.MinInt {
+		return 0, fmt.Errorf("%w for %s: %d", ErrOutOfRange, key, n)
+	}
+	return int(n), nil
+}
+
+// GetBoolOrInt reads key like git reads its bool-or-int settings (e.g.
+// core.abbrev): a value that is an integer, in the syntax of GetInt, is
+// returned as i with isBool false. Otherwise the value must be a boolean as
+// in GetBool, including the empty value for true, and is returned as b with
+// isBool true. Only the result selected by isBool is meaningful. A value
+// that is neither returns an ErrInvalidBool error, an integer that does not
+// fit an int an ErrOutOfRange error.
+func GetBoolOrInt(cfg map[string]string, key string) (isBool, b bool, i int, err error) {
+	value, err := lookup(cfg, key)
+	if err != nil {
+		return false, false, 0, err
+	}
+	n, err := parseInt64(value)
+	switch {
+	case err == nil:
+		if n > math.MaxInt || n < math.MinInt {
+			return false, false, 0, fmt.Errorf("%w for %s: %q", ErrOutOfRange, key, value)
+		}
+		return false, false, int(n), nil
+	case errors.Is(err, ErrOutOfRange):
+		return false, false, 0, fmt.Errorf("%w for %s: %q", err, key, value)
+	}
+	b, ok := parseBool(value)
+	if !ok {
+		return false, false, 0, fmt.Errorf("%w for %s: %q", ErrInvalidBool, key, value)
+	}
+	return true, b, 0, nil
+}
+
+func parseInt64(value string) (int64, error) {
+	num := strings.TrimSpace(value)
+	factor := int64(1)
+	if num != "" {
+		switch num[len(num)-1] {
+		case 'k', 'K':
+			factor = 1 << 10
+		case 'm', 'M':
+			factor = 1 << 20
+		case 'g', 'G':
+			factor = 1 << 30
+		}
+		if factor != 1 {
+			num = num[:len(num)-1]
+		}
+	}
+	if num == "" || strings.ContainsRune(num, '_') {
+		return 0, ErrInvalidInt
+	}
+	n, err := strconv.ParseInt(num, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, ErrOutOfRange
+	}
+	if err != nil {
+		return 0, ErrInvalidInt
+	}
+	if n > math.MaxInt64/factor || n < math.MinInt64/factor {
+		return 0, ErrOutOfRange
+	}
+	return n * factor, nil
+}
+
+// ListOption changes how GetStringList splits a value.
+type ListOption func(*listOptions)
+
+type listOptions struct {
+	keepEmpty bool
+}
+
+// KeepEmpty makes GetStringList keep the empty elements between two
+// consecutive separators, or before a leading or after a trailing one.
+func KeepEmpty() ListOption {
+	return func(o *listOptions) {
+		o.keepEmpty = true
+	}
+}
+
+// GetStringList returns the value of key split at every sep, e.g. "a, b,
+// c" split at "," is "a", "b" and "c", or nil if key is not set. The key
+// is normalized as in Get. Whitespace around each element is trimmed, and
+// elements that are empty after trimming are dropped unless KeepEmpty is
+// given; a set key with no elements gives an empty, non-nil list. If sep is
+// empty, the value is split at runs of whitespace, as by strings.Fields.
+func GetStringList(cfg map[string]string, key, sep string, opts ...ListOption) []string {
+	value, ok := find(cfg, key, nil)
+	if !ok {
+		return nil
+	}
+	if sep == "" {
+		return append([]string{}, strings.Fields(value)...)
+	}
+	var o listOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	list := []string{}
+	for _, element := range strings.Split(value, sep) {
+		element = strings.TrimSpace(element)
+		if element != "" || o.keepEmpty {
+			list = append(list, element)
+		}
+	}
+	return list
+}
+
+// GetPath returns the value of key as a path. Like git, a leading "~/" (or a
+// bare "~") is replaced by the home directory of the current user, and
+// "~name/" by the home directory of user name. Other values are returned
+// unchanged.
+func GetPath(cfg map[string]string, key string) (string, error) {
+	return Getter{}.GetPath(cfg, key)
+}
+
+// GetPath is like the function GetPath, with home directories looked up by
+// g.HomeDir.
+func (g Getter) GetPath(cfg map[string]string, key string) (string, error) {
+	value, err := lookup(cfg, key)
+	if err != nil {
+		return "", err
+	}
+	path, err := expandPath(value, g.homeDir)
+	if err != nil {
+		return "", fmt.Errorf("%w for %s: %v", ErrInvalidPath, key, err)
+	}
+	return path, nil
+}
+
+// expandPath expands a leading ~ in path with the home directories
+// returned by home.
+func expandPath(path string, home func(name string) (string, error)) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	name, rest := path[1:], ""
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+	dir, err := home(name)
+	if err != nil {
+		return "", err
+	}
+	return dir + rest, nil
+}
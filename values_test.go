@@ -0,0 +1,284 @@
+package goconfig
+
+import (
+	"errors"
+	"math"
+	"os/user"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBool(t *testing.T) {
+	config, _, err := Parse([]byte("[core]\n\tbare\n\tfilemode = Yes\n\tsymlinks = off\n" +
+		"\tignorecase = 0\n\tlogallrefupdates = TRUE\n\tautocrlf = input\n"))
+	assert.Equal(t, nil, err)
+
+	tests := map[string]bool{
+		"core.bare":             true,
+		"core.filemode":         true,
+		"core.symlinks":         false,
+		"core.ignorecase":       false,
+		"core.logallrefupdates": true,
+	}
+	for key, expected := range tests {
+		b, err := GetBool(config, key)
+		assert.Equal(t, nil, err, key)
+		assert.Equal(t, expected, b, key)
+	}
+
+	_, err = GetBool(config, "core.autocrlf")
+	assert.ErrorIs(t, err, ErrInvalidBool)
+	assert.Contains(t, err.Error(), "core.autocrlf")
+
+	_, err = GetBool(config, "core.missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestGetInt64(t *testing.T) {
+	config := map[string]string{
+		"pack.plain":     "42",
+		"pack.negative":  "-7",
+		"pack.kilo":      "1k",
+		"pack.mega":      "2M",
+		"pack.giga":      "3g",
+		"pack.hex":       "0x10",
+		"pack.hexkilo":   "0x10k",
+		"pack.max":       "9223372036854775807",
+		"pack.overflow":  "9223372036854775808",
+		"pack.overgiga":  "9000000000g",
+		"pack.invalid":   "12 apples",
+		"pack.suffix":    "k",
+		"pack.separator": "1_000",
+	}
+	tests := map[string]int64{
+		"pack.plain":    42,
+		"pack.negative": -7,
+		"pack.kilo":     1024,
+		"pack.mega":     2 * 1024 * 1024,
+		"pack.giga":     3 * 1024 * 1024 * 1024,
+		"pack.hex":      16,
+		"pack.hexkilo":  16 * 1024,
+		"pack.max":      9223372036854775807,
+	}
+	for key, expected := range tests {
+		n, err := GetInt64(config, key)
+		assert.Equal(t, nil, err, key)
+		assert.Equal(t, expected, n, key)
+	}
+
+	for _, key := range []string{"pack.overflow", "pack.overgiga"} {
+		_, err := GetInt64(config, key)
+		assert.ErrorIs(t, err, ErrOutOfRange, key)
+	}
+	for _, key := range []string{"pack.invalid", "pack.suffix", "pack.separator"} {
+		_, err := GetInt64(config, key)
+		assert.ErrorIs(t, err, ErrInvalidInt, key)
+	}
+	_, err := GetInt64(config, "pack.missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestGetInt(t *testing.T) {
+	config := map[string]string{
+		"core.packedgitlimit": "256m",
+		"core.bigfile":        "-1k",
+		"core.huge":           strconv.FormatInt(math.MaxInt64, 10) + "0",
+		"core.invalid":        "many",
+	}
+	n, err := GetInt(config, "core.packedgitlimit")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 256*1024*1024, n)
+
+	n, err = GetInt(config, "core.bigfile")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, -1024, n)
+
+	_, err = GetInt(config, "core.huge")
+	assert.ErrorIs(t, err, ErrOutOfRange)
+	assert.Contains(t, err.Error(), "core.huge")
+
+	_, err = GetInt(config, "core.invalid")
+	assert.ErrorIs(t, err, ErrInvalidInt)
+	assert.Contains(t, err.Error(), "core.invalid")
+}
+
+func TestGetBoolOrInt(t *testing.T) {
+	config := map[string]string{
+		"core.abbrev":  "12",
+		"diff.context": "0x10",
+		"core.one":     "1",
+		"core.auto":    "Yes",
+		"core.off":     "off",
+		"core.bare":    "",
+		"core.huge":    strconv.FormatInt(math.MaxInt64, 10) + "0",
+		"core.invalid": "auto",
+	}
+	for key, expected := range map[string]struct {
+		isBool, b bool
+		i         int
+	}{
+		"core.abbrev":  {i: 12},
+		"diff.context": {i: 16},
+		"core.one":     {i: 1},
+		"core.auto":    {isBool: true, b: true},
+		"core.off":     {isBool: true},
+		"core.bare":    {isBool: true, b: true},
+	} {
+		isBool, b, i, err := GetBoolOrInt(config, key)
+		assert.Equal(t, nil, err, key)
+		assert.Equal(t, expected.isBool, isBool, key)
+		assert.Equal(t, expected.b, b, key)
+		assert.Equal(t, expected.i, i, key)
+	}
+
+	_, _, _, err := GetBoolOrInt(config, "core.huge")
+	assert.ErrorIs(t, err, ErrOutOfRange)
+	_, _, _, err = GetBoolOrInt(config, "core.invalid")
+	assert.ErrorIs(t, err, ErrInvalidBool)
+	assert.Contains(t, err.Error(), "core.invalid")
+	_, _, _, err = GetBoolOrInt(config, "core.missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestGetBoolState(t *testing.T) {
+	config, _, err := Parse([]byte(`[core]
+	bare
+	empty =
+	yes = YES
+	on = on
+	one = 1
+	off = Off
+	no = no
+	zero = 0
+	invalid = maybe
+[remote "Origin"]
+	prune = false
+`))
+	assert.Equal(t, nil, err)
+	tests := map[string]BoolState{
+		"core.bare":           BoolTrue,
+		"core.empty":          BoolTrue,
+		"core.yes":            BoolTrue,
+		"core.on":             BoolTrue,
+		"core.one":            BoolTrue,
+		"core.off":            BoolFalse,
+		"core.no":             BoolFalse,
+		"core.zero":           BoolFalse,
+		"Core.Bare":           BoolTrue,
+		"remote.Origin.prune": BoolFalse,
+		"core.missing":        BoolUnset,
+		"remote.origin.prune": BoolUnset,
+	}
+	for key, expected := range tests {
+		state, err := GetBoolState(config, key)
+		assert.Equal(t, nil, err, key)
+		assert.Equal(t, expected, state, key)
+	}
+
+	state, err := GetBoolState(config, "core.invalid")
+	assert.ErrorIs(t, err, ErrInvalidBool)
+	assert.Contains(t, err.Error(), "core.invalid")
+	assert.Equal(t, BoolUnset, state)
+
+	// Unlike GetBool, an unset key is not an error.
+	_, err = GetBool(config, "core.missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	assert.Equal(t, "unset", BoolUnset.String())
+	assert.Equal(t, "true", BoolTrue.String())
+	assert.Equal(t, "false", BoolFalse.String())
+	assert.Equal(t, "BoolState(7)", BoolState(7).String())
+}
+
+func TestGetPath(t *testing.T) {
+	t.Setenv("HOME", "/home/danyel")
+	config := map[string]string{
+		"core.excludesfile": "~/.gitignore",
+		"core.home":         "~",
+		"core.absolute":     "/etc/gitignore",
+		"core.relative":     "hooks/~x",
+		"core.nouser":       "~no-such-user-here/x",
+	}
+	tests := map[string]string{
+		"core.excludesfile": "/home/danyel/.gitignore",
+		"core.home":         "/home/danyel",
+		"core.absolute":     "/etc/gitignore",
+		"core.relative":     "hooks/~x",
+	}
+	for key, expected := range tests {
+		path, err := GetPath(config, key)
+		assert.Equal(t, nil, err, key)
+		assert.Equal(t, expected, path, key)
+	}
+
+	_, err := GetPath(config, "core.nouser")
+	assert.ErrorIs(t, err, ErrInvalidPath)
+
+	if u, err := user.Current(); err == nil {
+		config["core.user"] = "~" + u.Username + "/x"
+		path, err := GetPath(config, "core.user")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, u.HomeDir+"/x", path)
+	}
+}
+
+func TestGetterGetPath(t *testing.T) {
+	t.Setenv("HOME", "/home/danyel")
+	var asked []string
+	g := Getter{HomeDir: func(name string) (string, error) {
+		asked = append(asked, name)
+		switch name {
+		case "":
+			return "/home/test", nil
+		case "nobody":
+			return "", errors.New("no such user")
+		}
+		return "/users/" + name, nil
+	}}
+	config := map[string]string{
+		"core.excludesfile": "~/.gitignore",
+		"core.hookspath":    "~alice/hooks",
+		"core.absolute":     "/etc/gitignore",
+		"core.nouser":       "~nobody/x",
+	}
+	tests := map[string]string{
+		"core.excludesfile": "/home/test/.gitignore",
+		"core.hookspath":    "/users/alice/hooks",
+		"core.absolute":     "/etc/gitignore",
+	}
+	for key, expected := range tests {
+		path, err := g.GetPath(config, key)
+		assert.Equal(t, nil, err, key)
+		assert.Equal(t, expected, path, key)
+	}
+	_, err := g.GetPath(config, "core.nouser")
+	assert.ErrorIs(t, err, ErrInvalidPath)
+	assert.Contains(t, err.Error(), "no such user")
+	assert.ElementsMatch(t, []string{"", "alice", "nobody"}, asked)
+	_, err = g.GetPath(config, "core.missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	path, err := Getter{}.GetPath(config, "core.excludesfile")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "/home/danyel/.gitignore", path)
+}
+
+func TestGetStringList(t *testing.T) {
+	config := map[string]string{
+		"x.list":   " a, b ,c,, d ,",
+		"x.spaced": "  one\ttwo  three ",
+		"x.empty":  "",
+		"x.single": "alone",
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, GetStringList(config, "X.List", ","))
+	assert.Equal(t, []string{"a", "b", "c", "", "d", ""}, GetStringList(config, "x.list", ",", KeepEmpty()))
+	assert.Equal(t, []string{"a, b ,c,, d ,"}, GetStringList(config, "x.list", ";"))
+	assert.Equal(t, []string{"one", "two", "three"}, GetStringList(config, "x.spaced", ""))
+	assert.Equal(t, []string{"alone"}, GetStringList(config, "x.single", ","))
+	assert.Equal(t, []string{}, GetStringList(config, "x.empty", ","))
+	assert.Equal(t, []string{}, GetStringList(config, "x.empty", ""))
+	assert.Equal(t, []string{""}, GetStringList(config, "x.empty", ",", KeepEmpty()))
+	assert.Nil(t, GetStringList(config, "x.missing", ","))
+}